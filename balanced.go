@@ -0,0 +1,108 @@
+package goblex
+
+import "unicode/utf8"
+
+// CaptureBalanced reads a block delimited by open and close from the input stream, tracking nested
+// pairs of open and close, writes it to the capture buffer including the outer delimiters and returns
+// whether a balanced block was captured, e.g. CaptureBalanced("(", ")") captures all of
+// "(a, (b, c), f(d))".
+//
+// Any quotes given are treated as string delimiters and open or close tokens found within those
+// strings are not counted. A backslash within a string escapes the rune following it.
+//
+// If the current position is not on open nothing is captured and false is returned. If the block is
+// never closed an ErrUnbalanced error is reported and the lexer is rewound to the opening delimiter so no
+// input is lost.
+//
+// Whitespace before and after the block is discarded if AutoEatWhitespace is set, but whitespace inside
+// the block is always captured. Ignore tokens inside the block are skipped.
+func (lxr *Lexer) CaptureBalanced(open, close string, quotes ...string) bool {
+	lxr.enterDebug("CaptureBalanced")
+	lxr.eatLeading()
+	if open == "" || close == "" || !lxr.CurrentTokenIs(open) {
+		lxr.exitDebug("CaptureBalanced")
+		return false
+	}
+
+	start := lxr.currentPos
+	m := lxr.Mark()
+	openLen := utf8.RuneCountInString(open)
+	closeLen := utf8.RuneCountInString(close)
+	depth := 0
+	unterminatedQuote := ""
+
+	for !lxr.eof {
+		if depth > 0 && lxr.skipIgnores() {
+			continue
+		}
+
+		if depth > 0 && lxr.CurrentTokenIs(close) {
+			lxr.captureRunes(closeLen)
+			depth--
+			if depth == 0 {
+				break
+			}
+			continue
+		}
+
+		if lxr.CurrentTokenIs(open) {
+			lxr.captureRunes(openLen)
+			depth++
+			continue
+		}
+
+		if found, quote := lxr.CurrentTokenIsOneOf(quotes...); found {
+			if !lxr.captureQuotedRaw(quote) {
+				unterminatedQuote = quote
+				break
+			}
+			continue
+		}
+
+		lxr.capture()
+		lxr.read()
+	}
+
+	if depth > 0 {
+		lxr.Rewind(m)
+		if unterminatedQuote != "" {
+			lxr.errorf(CodeUnbalanced, lxr.currentPos, "unbalanced %q starting at %s: unterminated %s string", open, start, unterminatedQuote)
+		} else {
+			lxr.errorf(CodeUnbalanced, lxr.currentPos, "unbalanced %q starting at %s: missing %q", open, start, close)
+		}
+		lxr.exitDebug("CaptureBalanced")
+		return false
+	}
+
+	lxr.Commit(m)
+	lxr.eatTrailing()
+	lxr.exitDebug("CaptureBalanced")
+	return true
+}
+
+// captureQuotedRaw captures a string delimited by quote as is, treating a backslash as escaping the
+// following rune, and returns whether the closing quote was found.
+func (lxr *Lexer) captureQuotedRaw(quote string) bool {
+	quoteLen := utf8.RuneCountInString(quote)
+	lxr.captureRunes(quoteLen)
+
+	for !lxr.eof {
+		if lxr.CurrentTokenIs(quote) {
+			lxr.captureRunes(quoteLen)
+			return true
+		}
+
+		if lxr.currentRune == '\\' {
+			lxr.capture()
+			lxr.read()
+			if lxr.eof {
+				break
+			}
+		}
+
+		lxr.capture()
+		lxr.read()
+	}
+
+	return false
+}
@@ -0,0 +1,108 @@
+package goblex_test
+
+import (
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *GoblexTestSuite) TestCaptureBalanced() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("balanced", `Anno (a, (b, c), f(d)) next`, lexFun)
+	l.CaptureIdent()
+	l.Flush()
+
+	assert.False(suite.T(), l.CaptureBalanced("[", "]"))
+	assert.True(suite.T(), l.CaptureBalanced("(", ")"))
+	assert.Equal(suite.T(), "(a, (b, c), f(d))", l.Flush())
+
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "next", l.Flush())
+}
+
+func (suite *GoblexTestSuite) TestCaptureBalancedQuotes() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	input := `{ "a": "}", 'b': '{\'', c: { } } rest`
+
+	l := goblex.NewLexer("balanced", input, lexFun)
+	assert.True(suite.T(), l.CaptureBalanced("{", "}", `"`, "'"))
+	assert.Equal(suite.T(), `{ "a": "}", 'b': '{\'', c: { } }`, l.Flush())
+
+	l = goblex.NewLexer("balanced", input, lexFun)
+	assert.True(suite.T(), l.CaptureBalanced("{", "}"))
+	assert.Equal(suite.T(), `{ "a": "}`, l.Flush())
+}
+
+func (suite *GoblexTestSuite) TestCaptureBalancedMultiRuneAndIgnores() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("balanced", "{{ a {{ b }} /* }} */ c }}", lexFun)
+	l.AddIgnoreRange("/*", "*/", false)
+
+	assert.True(suite.T(), l.CaptureBalanced("{{", "}}"))
+	assert.Equal(suite.T(), "{{ a {{ b }}  c }}", l.Flush())
+	assert.True(suite.T(), l.IsEOF())
+}
+
+func (suite *GoblexTestSuite) TestCaptureBalancedSameDelimiters() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("balanced", "|a b| c|", lexFun)
+
+	assert.True(suite.T(), l.CaptureBalanced("|", "|"))
+	assert.Equal(suite.T(), "|a b|", l.Flush())
+}
+
+func (suite *GoblexTestSuite) TestCaptureBalancedUnbalanced() {
+	suite.T().Parallel()
+	var errs []string
+	var values []string
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		if !lexer.CaptureBalanced("(", ")", `"`) {
+			lexer.CaptureUntil(false, "!")
+			lexer.Emit(basicTokenType)
+		}
+		return nil
+	}
+
+	for _, input := range []string{"(a, (b, c) rest!", `(a, ")) rest!`} {
+		l := goblex.NewLexer("balanced", input, lexFun)
+		for {
+			token := l.NextEmittedToken()
+			if token.Type() == goblex.TokenTypeEOF {
+				break
+			}
+
+			if token.Type() == goblex.TokenTypeError {
+				errs = append(errs, token.String())
+			} else {
+				values = append(values, token.String())
+			}
+		}
+	}
+
+	assert.Equal(suite.T(), []string{
+		`unbalanced "(" starting at 1:1: missing ")"`,
+		`unbalanced "(" starting at 1:1: unterminated " string`,
+	}, errs)
+	assert.Equal(suite.T(), []string{"(a, (b, c) rest", `(a, ")) rest`}, values)
+}
@@ -1,13 +1,15 @@
 package goblex
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 func Example() {
 	input := "I like #unicorns and #cheese"
 	var tagType TokenType = 1
 	var lexTagname LexFn
 	var lexPound LexFn
-	var token Token
 
 	lexPound = func(l *Lexer) LexFn {
 		if l.CaptureUntil(true, "#") {
@@ -28,12 +30,7 @@ func Example() {
 
 	l := NewLexer("myLexer", input, lexPound)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := range l.All() {
 		switch token.Type() {
 		case tagType:
 			fmt.Print(token.String() + ",")
@@ -57,6 +54,29 @@ func ExampleNewLexer() {
 	fmt.Println(l.Name)
 }
 
+func ExampleNewLexerFromReader() {
+	var hashtag TokenType = 1
+
+	fn := func(l *Lexer) LexFn {
+		l.CaptureUntil(true, "#")
+		l.ConsumeCurrentToken(true)
+		l.CaptureIdent()
+		l.Emit(hashtag)
+		return nil
+	}
+
+	// any io.Reader can be used, e.g. an *os.File or a network connection
+	l := NewLexerFromReader("myLexer", strings.NewReader("some #text"), fn)
+
+	for token := range l.All() {
+		if token.Type() == hashtag {
+			fmt.Println(token.String())
+		}
+	}
+
+	// Output: #text
+}
+
 func ExampleLexer_AddIgnoreTokens() {
 	fn := func(l *Lexer) LexFn { return nil }
 
@@ -83,7 +103,6 @@ func ExampleLexer_RemoveIgnoreTokens() {
 
 func ExampleLexer_NextEmittedToken() {
 	var hashtag TokenType = 1
-	var token Token
 	var tokenValue = ""
 
 	fn := func(l *Lexer) LexFn {
@@ -96,12 +115,7 @@ func ExampleLexer_NextEmittedToken() {
 
 	l := NewLexer("myLexer", "some #text", fn)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := l.NextEmittedToken(); token.Type() != TokenTypeEOF; token = l.NextEmittedToken() {
 		switch token.Type() {
 		case hashtag:
 			tokenValue = token.String()
@@ -114,7 +128,6 @@ func ExampleLexer_NextEmittedToken() {
 
 func ExampleLexer_CaptureUntil_skipwhitespace() {
 	var chars TokenType = 1
-	var token Token
 	var tokenValue = ""
 
 	fn := func(l *Lexer) LexFn {
@@ -126,12 +139,7 @@ func ExampleLexer_CaptureUntil_skipwhitespace() {
 
 	l := NewLexer("myLexer", "some text!", fn)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := range l.All() {
 		switch token.Type() {
 		case chars:
 			tokenValue = token.String()
@@ -144,7 +152,6 @@ func ExampleLexer_CaptureUntil_skipwhitespace() {
 
 func ExampleLexer_CaptureUntil_includewhitespace() {
 	var chars TokenType = 1
-	var token Token
 	var tokenValue = ""
 
 	fn := func(l *Lexer) LexFn {
@@ -156,12 +163,7 @@ func ExampleLexer_CaptureUntil_includewhitespace() {
 
 	l := NewLexer("myLexer", "some text!", fn)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := range l.All() {
 		switch token.Type() {
 		case chars:
 			tokenValue = token.String()
@@ -174,7 +176,6 @@ func ExampleLexer_CaptureUntil_includewhitespace() {
 
 func ExampleLexer_CaptureUntilOneOf() {
 	var chars TokenType = 1
-	var token Token
 	var tokenValue = ""
 
 	fn := func(l *Lexer) LexFn {
@@ -186,12 +187,7 @@ func ExampleLexer_CaptureUntilOneOf() {
 
 	l := NewLexer("myLexer", "some #text!", fn)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := range l.All() {
 		switch token.Type() {
 		case chars:
 			tokenValue = token.String()
@@ -204,7 +200,6 @@ func ExampleLexer_CaptureUntilOneOf() {
 
 func ExampleLexer_CaptureIdent() {
 	var ident TokenType = 1
-	var token Token
 	var tokenValue = ""
 
 	fn := func(l *Lexer) LexFn {
@@ -216,12 +211,7 @@ func ExampleLexer_CaptureIdent() {
 
 	l := NewLexer("myLexer", "some text!", fn)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := range l.All() {
 		switch token.Type() {
 		case ident:
 			tokenValue = token.String()
@@ -234,7 +224,6 @@ func ExampleLexer_CaptureIdent() {
 
 func ExampleLexer_ConsumeCurrentToken_newbuffer() {
 	var chars TokenType = 1
-	var token Token
 	var tokenValue = ""
 
 	fn := func(l *Lexer) LexFn {
@@ -248,12 +237,7 @@ func ExampleLexer_ConsumeCurrentToken_newbuffer() {
 
 	l := NewLexer("myLexer", "some #text", fn)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := range l.All() {
 		switch token.Type() {
 		case chars:
 			tokenValue = token.String()
@@ -266,7 +250,6 @@ func ExampleLexer_ConsumeCurrentToken_newbuffer() {
 
 func ExampleLexer_ConsumeCurrentToken_oldbuffer() {
 	var chars TokenType = 1
-	var token Token
 	var tokenValue = ""
 
 	fn := func(l *Lexer) LexFn {
@@ -280,12 +263,7 @@ func ExampleLexer_ConsumeCurrentToken_oldbuffer() {
 
 	l := NewLexer("myLexer", "some #text", fn)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := range l.All() {
 		switch token.Type() {
 		case chars:
 			tokenValue = token.String()
@@ -298,7 +276,6 @@ func ExampleLexer_ConsumeCurrentToken_oldbuffer() {
 
 func ExampleLexer_SkipCurrentToken_newbuffer() {
 	var chars TokenType = 1
-	var token Token
 	var tokenValue = ""
 
 	fn := func(l *Lexer) LexFn {
@@ -312,12 +289,7 @@ func ExampleLexer_SkipCurrentToken_newbuffer() {
 
 	l := NewLexer("myLexer", "some #text", fn)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := range l.All() {
 		switch token.Type() {
 		case chars:
 			tokenValue = token.String()
@@ -330,7 +302,6 @@ func ExampleLexer_SkipCurrentToken_newbuffer() {
 
 func ExampleLexer_SkipCurrentToken_oldbuffer() {
 	var chars TokenType = 1
-	var token Token
 	var tokenValue = ""
 
 	fn := func(l *Lexer) LexFn {
@@ -344,12 +315,7 @@ func ExampleLexer_SkipCurrentToken_oldbuffer() {
 
 	l := NewLexer("myLexer", "some #text", fn)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := range l.All() {
 		switch token.Type() {
 		case chars:
 			tokenValue = token.String()
@@ -362,7 +328,6 @@ func ExampleLexer_SkipCurrentToken_oldbuffer() {
 
 func ExampleLexer_CurrentTokenIs() {
 	var chars TokenType = 1
-	var token Token
 	var tokenValue = ""
 
 	fn := func(l *Lexer) LexFn {
@@ -376,12 +341,7 @@ func ExampleLexer_CurrentTokenIs() {
 
 	l := NewLexer("myLexer", "some #text", fn)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := range l.All() {
 		switch token.Type() {
 		case chars:
 			tokenValue = token.String()
@@ -413,7 +373,6 @@ func (s sliceToken) Slice() []string {
 
 func ExampleLexer_EmitToken() {
 
-	var token Token
 	var tokenValue = ""
 
 	customToken := sliceToken{tokenType: sliceType, slice: make([]string, 0)}
@@ -432,12 +391,7 @@ func ExampleLexer_EmitToken() {
 
 	l := NewLexer("myLexer", "some text!", fn)
 
-	for {
-		if l.IsEOF() {
-			break
-		}
-
-		token = l.NextEmittedToken()
+	for token := range l.All() {
 		switch token.Type() {
 		case sliceType:
 			ct := token.(sliceToken)
@@ -450,3 +404,132 @@ func ExampleLexer_EmitToken() {
 	fmt.Println(tokenValue)
 	// Output: sometext
 }
+
+func ExampleNewTypedLexer() {
+	var wordType TokenType = 1
+
+	type word struct {
+		Text string
+		Line int
+	}
+
+	fn := func(l *Lexer) LexFn {
+		for l.CaptureIdent() {
+			l.Emit(wordType)
+		}
+		return nil
+	}
+
+	l := NewTypedLexer(NewLexer("myLexer", "some\ntext", fn), func(info TokenInfo) word {
+		if info.Type != wordType {
+			return word{}
+		}
+		return word{Text: info.Value, Line: info.Start.Line}
+	})
+
+	for w := l.NextEmittedToken(); w.Text != ""; w = l.NextEmittedToken() {
+		fmt.Printf("%s@%d ", w.Text, w.Line)
+	}
+
+	// Output: some@1 text@2
+}
+
+// registering a name twice panics, so token types are registered in package level variables
+var hashtagType = RegisterTokenType("HASHTAG")
+
+func ExampleRegisterTokenType() {
+	fn := func(l *Lexer) LexFn {
+		l.CaptureUntil(true, "#")
+		l.ConsumeCurrentToken(true)
+		l.CaptureIdent()
+		l.Emit(hashtagType)
+		return nil
+	}
+
+	l := NewLexer("myLexer", "some #text", fn)
+
+	for token := l.NextEmittedToken(); ; token = l.NextEmittedToken() {
+		fmt.Printf("%s %q\n", token.Type(), token.String())
+		if token.Type() == TokenTypeEOF {
+			break
+		}
+	}
+
+	// Output:
+	// HASHTAG "#text"
+	// EOF "EOF"
+}
+
+var (
+	exampleNumberType = RegisterTokenTypeInCategory("EXAMPLE_NUMBER", CategoryLiteral)
+	exampleStringType = RegisterTokenTypeInCategory("EXAMPLE_STRING", CategoryLiteral)
+	examplePlusType   = RegisterTokenTypeInCategory("EXAMPLE_PLUS", CategoryOperator)
+)
+
+func ExampleTokenTypesIn() {
+	literals := TokenTypesIn(CategoryLiteral)
+
+	for _, tokenType := range []TokenType{exampleNumberType, examplePlusType, exampleStringType} {
+		fmt.Println(tokenType, tokenType.Category(), literals.Contains(tokenType))
+	}
+
+	// Output:
+	// EXAMPLE_NUMBER literal true
+	// EXAMPLE_PLUS operator false
+	// EXAMPLE_STRING literal true
+}
+
+func ExampleLexer_All() {
+	var word TokenType = 1
+
+	fn := func(l *Lexer) LexFn {
+		for l.CaptureIdent() {
+			l.Emit(word)
+		}
+		return nil
+	}
+
+	l := NewLexer("myLexer", "some text", fn)
+
+	for token := range l.All() {
+		fmt.Printf("%s %q\n", token.Type(), token.String())
+	}
+
+	// Output:
+	// TokenType(1) "some"
+	// TokenType(1) "text"
+	// EOF "EOF"
+}
+
+func ExampleLexer_AllWithErrors() {
+	var word TokenType = 1
+
+	var fn LexFn
+	fn = func(l *Lexer) LexFn {
+		if l.IsEOF() {
+			return nil
+		}
+
+		if !l.CaptureIdent() {
+			return l.Errorf("expected a word")
+		}
+
+		l.Emit(word)
+		return fn
+	}
+
+	l := NewLexer("myLexer", "some !text", fn)
+
+	for token, err := range l.AllWithErrors() {
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+
+		fmt.Println(token.String())
+	}
+
+	// Output:
+	// some
+	// 1:6: expected a word
+}
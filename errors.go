@@ -0,0 +1,181 @@
+package goblex
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode classifies the errors reported by a Lexer so parsers can react to them programmatically.
+type ErrorCode int
+
+const (
+	// CodeNone is the code of errors reported via Errorf
+	CodeNone ErrorCode = iota
+	// CodeInput is the code of errors returned by the reader of the input
+	CodeInput
+	// CodeUnterminatedString is the code of quoted strings that are never closed
+	CodeUnterminatedString
+	// CodeInvalidEscape is the code of invalid escape sequences in quoted strings
+	CodeInvalidEscape
+	// CodeMalformedNumber is the code of malformed numeric literals
+	CodeMalformedNumber
+	// CodeUnbalanced is the code of blocks captured by CaptureBalanced that are never closed
+	CodeUnbalanced
+	// CodeIndentation is the code of inconsistent indentation found while tracking Indentation
+	CodeIndentation
+	// CodeModeStack is the code of misuses of the mode stack: PushMode with a nil mode or PopMode without
+	// a matching PushMode
+	CodeModeStack
+	// CodeTooManyErrors is the code of the error reported when Recovery's MaxErrors is reached
+	CodeTooManyErrors
+
+	// CodeUser is the first code that lexer implementations should use for their own errors
+	CodeUser ErrorCode = 1000
+)
+
+var (
+	// ErrInput matches errors returned by the reader of the input
+	ErrInput = &LexError{Code: CodeInput, Msg: "error reading input"}
+	// ErrUnterminatedString matches quoted strings that are never closed
+	ErrUnterminatedString = &LexError{Code: CodeUnterminatedString, Msg: "unterminated string"}
+	// ErrInvalidEscape matches invalid escape sequences in quoted strings
+	ErrInvalidEscape = &LexError{Code: CodeInvalidEscape, Msg: "invalid escape sequence"}
+	// ErrMalformedNumber matches malformed numeric literals
+	ErrMalformedNumber = &LexError{Code: CodeMalformedNumber, Msg: "malformed number"}
+	// ErrUnbalanced matches blocks captured by CaptureBalanced that are never closed
+	ErrUnbalanced = &LexError{Code: CodeUnbalanced, Msg: "unbalanced block"}
+	// ErrIndentation matches inconsistent indentation
+	ErrIndentation = &LexError{Code: CodeIndentation, Msg: "inconsistent indentation"}
+	// ErrModeStack matches misuses of the mode stack: PushMode with a nil mode or PopMode without a
+	// matching PushMode
+	ErrModeStack = &LexError{Code: CodeModeStack, Msg: "invalid mode stack operation"}
+	// ErrTooManyErrors matches the error reported when Recovery's MaxErrors is reached
+	ErrTooManyErrors = &LexError{Code: CodeTooManyErrors, Msg: "too many errors"}
+)
+
+// LexError is an error found while lexing.
+//
+// LexErrors with the same non zero Code match each other with errors.Is, so errors.Is(err,
+// ErrUnterminatedString) reports whether err is an unterminated string. The wrapped cause, if any, can be
+// retrieved with errors.Unwrap or matched with errors.Is and errors.As.
+type LexError struct {
+	// Pos is the position in the input the error was found at
+	Pos Position
+	// Msg is the error message
+	Msg string
+	// Code classifies the error
+	Code ErrorCode
+	// Err is the error that caused this error, if any
+	Err error
+}
+
+// Error returns the message of the error prefixed with its position if it is known.
+func (e *LexError) Error() string {
+	if e.Pos.Line == 0 {
+		return e.Msg
+	}
+
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// Unwrap returns the error that caused this error.
+func (e *LexError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is a LexError with the same non zero Code.
+func (e *LexError) Is(target error) bool {
+	t, ok := target.(*LexError)
+	return ok && t.Code != CodeNone && t.Code == e.Code
+}
+
+// ErrorToken is a Token that carries the error it reports.
+//
+// All tokens with TokenTypeError emitted by the lexer implement this interface.
+type ErrorToken interface {
+	Token
+
+	// Err returns the reported error, which is always a *LexError
+	Err() error
+}
+
+// EmitError emits a Token with TokenTypeError as it's type reporting err and returns nil, or a LexFn
+// recovering from the error if Recovery is set, so it can be used as the return value of a LexFn.
+//
+// If err is a *LexError it is reported as is, positioned at the current rune if it has no position.
+// Any other error is wrapped in a LexError with the Code of the first *LexError found in it's chain, or
+// CodeNone if there is none. A nil err, including a nil *LexError, is reported as an unknown error.
+func (lxr *Lexer) EmitError(err error) LexFn {
+	lexErr, ok := err.(*LexError)
+	switch {
+	case err == nil, ok && lexErr == nil:
+		lexErr = &LexError{Msg: "unknown error"}
+	case !ok:
+		lexErr = &LexError{Msg: err.Error(), Err: err}
+		var cause *LexError
+		if errors.As(err, &cause) {
+			lexErr.Code = cause.Code
+		}
+	}
+
+	if lexErr.Pos.Line == 0 {
+		e := *lexErr
+		e.Pos = lxr.currentPos
+		lexErr = &e
+	}
+
+	lxr.reportError(lexErr)
+	return lxr.recoveryFn()
+}
+
+// Err returns the first error reported while lexing or nil if there was none.
+func (lxr *Lexer) Err() error {
+	if lxr.firstErr == nil {
+		return nil
+	}
+
+	return lxr.firstErr
+}
+
+// LastErr returns the last error reported while lexing or nil if there was none.
+func (lxr *Lexer) LastErr() error {
+	if lxr.lastErr == nil {
+		return nil
+	}
+
+	return lxr.lastErr
+}
+
+// errorf reports an error with code positioned at pos formatting the message like fmt.Errorf so that
+// errors passed for a %w verb become the cause. With several %w verbs, the cause is an error wrapping all
+// of them.
+func (lxr *Lexer) errorf(code ErrorCode, pos Position, format string, args ...interface{}) {
+	err := fmt.Errorf(format, args...)
+
+	var cause error
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() []error }:
+		cause = err
+	case interface{ Unwrap() error }:
+		cause = wrapped.Unwrap()
+	}
+
+	lxr.reportError(&LexError{Pos: pos, Msg: err.Error(), Code: code, Err: cause})
+}
+
+func (lxr *Lexer) reportError(err *LexError) {
+	if lxr.firstErr == nil {
+		lxr.firstErr = err
+	}
+	lxr.lastErr = err
+
+	lxr.pushDefault(defaultToken{
+		tokenType: TokenTypeError,
+		value:     err.Msg,
+		start:     err.Pos,
+		end:       err.Pos,
+		trivia:    lxr.takeErrorTrivia(err.Pos),
+		err:       err,
+	})
+	lxr.countError()
+}
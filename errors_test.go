@@ -0,0 +1,201 @@
+package goblex_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errMissingSemicolon = &goblex.LexError{Code: goblex.CodeUser + 1, Msg: "missing semicolon"}
+
+func (suite *GoblexTestSuite) collectErrors(l *goblex.Lexer) []error {
+	var errs []error
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		if token.Type() == goblex.TokenTypeError {
+			errs = append(errs, token.(goblex.ErrorToken).Err())
+		}
+	}
+
+	return errs
+}
+
+func (suite *GoblexTestSuite) TestErrorfWrapsCause() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureIdent()
+		return lexer.Errorf("could not lex %q: %w", lexer.Flush(), io.ErrUnexpectedEOF)
+	}
+
+	l := goblex.NewLexer("errors", "abc\ndef", lexFun)
+	errs := suite.collectErrors(l)
+
+	assert.Len(suite.T(), errs, 1)
+	assert.True(suite.T(), errors.Is(errs[0], io.ErrUnexpectedEOF))
+	assert.Equal(suite.T(), `2:1: could not lex "abc": unexpected EOF`, errs[0].Error())
+
+	var lexErr *goblex.LexError
+	assert.True(suite.T(), errors.As(l.Err(), &lexErr))
+	assert.Equal(suite.T(), goblex.CodeNone, lexErr.Code)
+	assert.Equal(suite.T(), `could not lex "abc": unexpected EOF`, lexErr.Msg)
+	assert.Equal(suite.T(), 2, lexErr.Pos.Line)
+	assert.Equal(suite.T(), io.ErrUnexpectedEOF, errors.Unwrap(lexErr))
+}
+
+func (suite *GoblexTestSuite) TestErrorfWrapsSeveralCauses() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return lexer.Errorf("%w and %w", io.ErrUnexpectedEOF, io.ErrShortBuffer)
+	}
+
+	l := goblex.NewLexer("errors", "abc", lexFun)
+	errs := suite.collectErrors(l)
+
+	assert.Len(suite.T(), errs, 1)
+	assert.True(suite.T(), errors.Is(errs[0], io.ErrUnexpectedEOF))
+	assert.True(suite.T(), errors.Is(errs[0], io.ErrShortBuffer))
+	assert.Equal(suite.T(), "1:1: unexpected EOF and short buffer", errs[0].Error())
+}
+
+func (suite *GoblexTestSuite) TestBuiltinErrorCodes() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureNumber()
+		lexer.CaptureQuoted(`"`, goblex.EscapeGo, false)
+		lexer.CaptureBalanced("(", ")")
+		// skip past the rewound "(" to the unterminated string
+		lexer.CaptureUntil(true, "a")
+		lexer.SkipCurrentToken(true)
+		lexer.CaptureQuoted(`"`, goblex.EscapeGo, false)
+		return lexer.PopMode()
+	}
+
+	l := goblex.NewLexer("errors", `0x "\q" (a "b`, lexFun)
+	errs := suite.collectErrors(l)
+
+	assert.Len(suite.T(), errs, 5)
+	assert.True(suite.T(), errors.Is(errs[0], goblex.ErrMalformedNumber))
+	assert.True(suite.T(), errors.Is(errs[1], goblex.ErrInvalidEscape))
+	assert.True(suite.T(), errors.Is(errs[2], goblex.ErrUnbalanced))
+	assert.True(suite.T(), errors.Is(errs[3], goblex.ErrUnterminatedString))
+	assert.True(suite.T(), errors.Is(errs[4], goblex.ErrModeStack))
+	assert.False(suite.T(), errors.Is(errs[4], goblex.ErrUnbalanced))
+
+	assert.Equal(suite.T(), errs[0], l.Err())
+	assert.Equal(suite.T(), errs[4], l.LastErr())
+}
+
+func (suite *GoblexTestSuite) TestEmitErrorCustom() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureIdent()
+		lexer.EmitError(errMissingSemicolon)
+		return lexer.EmitError(errors.New("plain error"))
+	}
+
+	l := goblex.NewLexer("errors", "abc", lexFun)
+	errs := suite.collectErrors(l)
+
+	assert.Len(suite.T(), errs, 2)
+	assert.True(suite.T(), errors.Is(errs[0], errMissingSemicolon))
+	assert.Equal(suite.T(), "1:4: missing semicolon", errs[0].Error())
+	assert.Equal(suite.T(), "missing semicolon", errMissingSemicolon.Error())
+	assert.Equal(suite.T(), "1:4: plain error", errs[1].Error())
+	assert.False(suite.T(), errors.Is(errs[1], errMissingSemicolon))
+}
+
+func (suite *GoblexTestSuite) TestEmitErrorWrapped() {
+	suite.T().Parallel()
+
+	cause := errors.New("disk on fire")
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return lexer.EmitError(fmt.Errorf("reading header: %w", errors.Join(goblex.ErrUnbalanced, cause)))
+	}
+
+	l := goblex.NewLexer("errors", "abc", lexFun)
+	errs := suite.collectErrors(l)
+
+	assert.Len(suite.T(), errs, 1)
+	assert.Equal(suite.T(), "1:1: reading header: unbalanced block\ndisk on fire", errs[0].Error())
+	var lexErr *goblex.LexError
+	suite.Require().True(errors.As(errs[0], &lexErr))
+	assert.Equal(suite.T(), goblex.CodeUnbalanced, lexErr.Code)
+	assert.True(suite.T(), errors.Is(errs[0], goblex.ErrUnbalanced))
+	assert.True(suite.T(), errors.Is(errs[0], cause))
+}
+
+func (suite *GoblexTestSuite) TestEmitErrorNil() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return lexer.EmitError(nil)
+	}
+
+	l := goblex.NewLexer("errors", "abc", lexFun)
+	errs := suite.collectErrors(l)
+
+	assert.Len(suite.T(), errs, 1)
+	assert.Equal(suite.T(), "1:1: unknown error", errs[0].Error())
+}
+
+func (suite *GoblexTestSuite) TestEmitErrorNilLexError() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		var lexErr *goblex.LexError
+		return lexer.EmitError(lexErr)
+	}
+
+	l := goblex.NewLexer("errors", "abc", lexFun)
+	errs := suite.collectErrors(l)
+
+	assert.Len(suite.T(), errs, 1)
+	assert.Equal(suite.T(), "1:1: unknown error", errs[0].Error())
+}
+
+func (suite *GoblexTestSuite) TestReaderErrorIsErrInput() {
+	suite.T().Parallel()
+
+	cause := errors.New("disk on fire")
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureUntil(true, "!")
+		lexer.Emit(basicTokenType)
+		return nil
+	}
+
+	l := goblex.NewLexerFromReader("errors", &errAfterReader{data: "I love unicorns", err: cause}, lexFun)
+	errs := suite.collectErrors(l)
+
+	assert.Len(suite.T(), errs, 1)
+	assert.True(suite.T(), errors.Is(errs[0], goblex.ErrInput))
+	assert.True(suite.T(), errors.Is(errs[0], cause))
+}
+
+func (suite *GoblexTestSuite) TestErrNoErrors() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureIdent()
+		lexer.Emit(basicTokenType)
+		return nil
+	}
+
+	l := goblex.NewLexer("errors", "abc", lexFun)
+	token := l.NextEmittedToken()
+
+	assert.Nil(suite.T(), token.(goblex.ErrorToken).Err())
+	assert.Nil(suite.T(), l.Err())
+	assert.Nil(suite.T(), l.LastErr())
+}
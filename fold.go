@@ -0,0 +1,53 @@
+package goblex
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldRune returns the canonical case folded form of ch, which is the smallest rune in ch's Unicode
+// simple case folding orbit, so that all runes that fold to each other map to the same rune.
+func foldRune(ch rune) rune {
+	min := ch
+	for r := unicode.SimpleFold(ch); r != ch; r = unicode.SimpleFold(r) {
+		if r < min {
+			min = r
+		}
+	}
+
+	return min
+}
+
+// foldString returns s with every rune replaced by it's canonical case folded form.
+func foldString(s string) string {
+	return strings.Map(foldRune, s)
+}
+
+// foldEqual returns whether a and b are equal under Unicode simple case folding.
+func foldEqual(a, b rune) bool {
+	return a == b || foldRune(a) == foldRune(b)
+}
+
+// runesEqual returns whether a and b are equal, folding case if fold is true.
+func runesEqual(fold bool, a, b rune) bool {
+	if fold {
+		return foldEqual(a, b)
+	}
+
+	return a == b
+}
+
+// runeSlicesEqual returns whether a and b hold the same runes, folding case if fold is true.
+func runeSlicesEqual(fold bool, a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !runesEqual(fold, a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
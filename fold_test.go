@@ -0,0 +1,105 @@
+package goblex_test
+
+import (
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *GoblexTestSuite) TestIgnoreCaseCaptureUntilOneOf() {
+	suite.T().Parallel()
+
+	match := func(l *goblex.Lexer) string {
+		return l.CaptureUntilOneOf(true, "from", "where")
+	}
+
+	exact := suite.lexOperators("select a FROM t Where b", func(l *goblex.Lexer) {}, match)
+	folded := suite.lexOperators("select a FROM t Where b", func(l *goblex.Lexer) { l.IgnoreCase = true }, match)
+
+	assert.Empty(suite.T(), exact)
+	assert.Equal(suite.T(), []string{"selecta", "FROM", "t", "Where"}, folded)
+}
+
+func (suite *GoblexTestSuite) TestCaptureUntilOneOfFold() {
+	suite.T().Parallel()
+
+	match := func(l *goblex.Lexer) string {
+		return l.CaptureUntilOneOfFold(true, "end")
+	}
+
+	values := suite.lexOperators("begin x END", func(l *goblex.Lexer) {}, match)
+
+	assert.Equal(suite.T(), []string{"beginx", "END"}, values)
+}
+
+func (suite *GoblexTestSuite) TestCurrentTokenIsOneOfFold() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "SELECT *", lexFun)
+
+	assert.False(suite.T(), l.CurrentTokenIs("select"))
+	assert.True(suite.T(), l.CurrentTokenIsFold("select"))
+
+	found, tkn := l.CurrentTokenIsOneOfFold("insert", "Select")
+	assert.True(suite.T(), found)
+	assert.Equal(suite.T(), "Select", tkn)
+
+	l.IgnoreCase = true
+	assert.True(suite.T(), l.CurrentTokenIs("sElEcT"))
+}
+
+func (suite *GoblexTestSuite) TestIgnoreCaseUnicode() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	// KELVIN SIGN and LATIN SMALL LETTER LONG S fold with k and s but simple folding never expands ß to ss
+	l := goblex.NewLexer("simple", "\u212Aey ſtraẞe été", lexFun)
+	l.IgnoreCase = true
+
+	assert.True(suite.T(), l.CurrentTokenIs("KEY"))
+	assert.False(suite.T(), l.CaptureUntil(true, "STRASSE"))
+
+	l = goblex.NewLexer("simple", "\u212Aey ſtraẞe été", lexFun)
+	l.IgnoreCase = true
+
+	assert.True(suite.T(), l.CaptureUntil(true, "STRAßE"))
+	assert.Equal(suite.T(), "\u212Aey", l.Flush())
+	assert.True(suite.T(), l.ConsumeCurrentToken(true))
+	assert.Equal(suite.T(), "ſtraẞe", l.Flush())
+	assert.True(suite.T(), l.CurrentTokenIs("ÉTÉ"))
+}
+
+func (suite *GoblexTestSuite) TestIgnoreCaseIgnoreTokens() {
+	suite.T().Parallel()
+
+	input := `a REM a ] in a comment
+	b /* a ] in a Comment END c] d]`
+
+	values := suite.lexUntilBracket(input, func(l *goblex.Lexer) {
+		l.IgnoreCase = true
+		l.AddIgnoreToEOL("rem")
+		l.AddIgnoreRange("/* a ] in a comment", "end", false)
+	})
+
+	assert.Equal(suite.T(), []string{"abc", "d"}, values)
+}
+
+func (suite *GoblexTestSuite) TestIgnoreCaseTokenSet() {
+	suite.T().Parallel()
+
+	ops := goblex.NewTokenSet("and", "or", "andalso")
+	match := func(l *goblex.Lexer) string {
+		return l.CaptureUntilIn(true, ops)
+	}
+
+	values := suite.lexOperators("a AND b Or c ANDALSO d", func(l *goblex.Lexer) { l.IgnoreCase = true }, match)
+
+	assert.Equal(suite.T(), []string{"a", "AND", "b", "Or", "c", "ANDALSO"}, values)
+}
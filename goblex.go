@@ -4,8 +4,8 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
-	"io/ioutil"
-	"reflect"
+	"io"
+	"os"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -27,56 +27,230 @@ type Lexer struct {
 	// AutoEatWhitespace is a flag to toggle discarding all *beginning* whitespace when capturing.
 	// defaults to true
 	AutoEatWhitespace bool
-	ignoreTokens      map[string]bool
-	inputBuffer       *bufio.Reader
-	tokens            chan Token
-	state             LexFn
-	begin             LexFn
-	tokenBuffer       bytes.Buffer
-	currentRune       rune
-	lastKnownToken    string
-	runeCache         []rune
-	logIndent         int
+	// LongestMatch is a flag that when set to true makes CurrentTokenIsOneOf and CaptureUntilOneOf
+	// find the longest of the given tokens rather than the first one in argument order, e.g. "<=" is
+	// found instead of "<" regardless of the order they are passed in. defaults to false
+	LongestMatch bool
+	// IgnoreCase is a flag that when set to true makes token matching, including ignore tokens and the
+	// tokens of a TokenSet, compare runes using Unicode simple case folding. The original text is still
+	// written to the capture buffer. defaults to false
+	IgnoreCase bool
+	// NumberFormat configures the numeric literals recognised by CaptureNumber.
+	// defaults to GoNumberFormat
+	NumberFormat NumberFormat
+	// IdentRule decides which runes make up an identifier captured by CaptureIdent.
+	// defaults to DefaultIdentRule
+	IdentRule IdentRule
+	// Keywords is the table used by EmitIdentOrKeyword to classify captured identifiers.
+	// defaults to nil
+	Keywords *KeywordTable
+	// Indentation enables emitting INDENT, DEDENT and NEWLINE tokens for indentation-sensitive input
+	// when set. defaults to nil
+	Indentation *Indentation
+	// PreserveTrivia is a flag that when set to true attaches the input skipped around emitted tokens to
+	// them as trivia, see TriviaToken. It must be set before lexing starts. defaults to false
+	PreserveTrivia bool
+	// Recovery makes Errorf recover from errors and continue lexing when set. defaults to nil which ends
+	// lexing at the first error reported via Errorf
+	Recovery       *Recovery
+	ignores        ignoreSet
+	inputBuffer    *bufio.Reader
+	inputCloser    io.Closer
+	inputErr       error
+	inputErrSent   bool
+	inputErrDue    bool
+	inputErrPos    Position
+	tokens         tokenQueue[Token]
+	sink           tokenSink
+	state          LexFn
+	begin          LexFn
+	tokenBuffer    bytes.Buffer
+	captureStarted bool
+	captureStart   Position
+	captureEnd     Position
+	flushed        *capturedSpan
+	currentRune    rune
+	currentSize    int
+	currentRaw     byte
+	currentPos     Position
+	eof            bool
+	lastKnownToken string
+	lastKnownFold  bool
+	lineIndent     lineIndent
+	captureIndent  lineIndent
+	indents        []int
+	layoutLine     int
+	layoutEnd      Position
+	mode           *Mode
+	modes          []savedMode
+	recordTrivia   bool
+	rawInput       []byte
+	rawBase        int
+	triviaStart    int
+	segments       []Segment
+	firstErr       *LexError
+	lastErr        *LexError
+	errCount       int
+	stopped        bool
+	recoverPos     Position
+	runeCache      []cachedRune
+	history        []cachedRune
+	markDepth      int
+	markIDs        []int
+	lastMarkID     int
+	logIndent      int
 }
 
 // NewLexer creates a new Lexer instance with the given name and set input as the text to parse using
 // the begin LexFn as the entry point when parsing.
 func NewLexer(name, input string, begin LexFn) *Lexer {
+	return newLexer(name, strings.NewReader(input), nil, begin)
+}
+
+// NewLexerFromReader creates a new Lexer instance with the given name that lexes the input read from r
+// using the begin LexFn as the entry point when parsing.
+//
+// The input is read incrementally as the lexer needs it, so r is never read into memory all at once.
+// Any error returned by r other than io.EOF ends the input and is emitted as a Token with
+// TokenTypeError as it's type once the LexFn that ran into it returns, after the tokens it emitted.
+func NewLexerFromReader(name string, r io.Reader, begin LexFn) *Lexer {
+	return newLexer(name, r, nil, begin)
+}
+
+// NewLexerFromFile creates a new Lexer instance with the given name that lexes the contents of the file
+// found at path using the begin LexFn as the entry point when parsing.
+//
+// The file is closed automatically when the end of it's input is reached. Consumers that stop lexing
+// early should call Close.
+func NewLexerFromFile(name, path string, begin LexFn) (*Lexer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLexer(name, f, f, begin), nil
+}
+
+func newLexer(name string, r io.Reader, closer io.Closer, begin LexFn) *Lexer {
 	l := &Lexer{
 		Name:              name,
 		Debug:             false,
 		AutoEatWhitespace: true,
-		ignoreTokens:      make(map[string]bool),
-		inputBuffer:       bufio.NewReader(strings.NewReader(input)),
+		NumberFormat:      GoNumberFormat,
+		IdentRule:         DefaultIdentRule,
+		inputCloser:       closer,
 		state:             begin,
 		begin:             begin,
-		tokens:            make(chan Token, 3),
+		currentPos:        Position{Offset: 0, Line: 1, Column: 1},
 		logIndent:         0,
+		inputBuffer:       bufio.NewReader(r),
+		recordTrivia:      true,
 	}
 
-	l.read()
+	l.fill()
 	return l
 }
 
-// AddIgnoreTokens adds the list of tokens to be ignored when capturing tokens to be emitted.
-// This can be called at anytime during lexing to ignore certain tokens from being captured.
-func (lxr *Lexer) AddIgnoreTokens(tokens ...string) {
+// cachedRune is a rune that has been peeked from the input along with it's size in bytes.
+type cachedRune struct {
+	ch   rune
+	size int
+	raw  byte
+}
+
+// ignoreRule describes what is skipped once an ignore token is found. A rule with no close and toEOL
+// unset skips only the token itself.
+type ignoreRule struct {
+	close  string
+	nested bool
+	toEOL  bool
+}
+
+// ignoreSet holds the ignore rules keyed by their open token. The open tokens are kept in a trie so
+// that overlapping tokens like "/" and "//" always resolve to the longest match.
+type ignoreSet struct {
+	rules map[string]ignoreRule
+	keys  trie
+}
+
+func (s *ignoreSet) add(open string, rule ignoreRule) {
+	if s.rules == nil {
+		s.rules = make(map[string]ignoreRule)
+	}
+
+	s.rules[open] = rule
+	s.keys.insert(open)
+}
+
+func (s *ignoreSet) remove(open string) {
+	delete(s.rules, open)
+	s.keys.remove(open)
+}
+
+func (s *ignoreSet) addTokens(tokens []string) {
 	for _, tkn := range tokens {
 		if strings.TrimSpace(tkn) != "" {
-			lxr.ignoreTokens[tkn] = true
+			s.add(tkn, ignoreRule{})
 		}
 	}
 }
 
+func (s *ignoreSet) addRange(open, close string, nested bool) {
+	if strings.TrimSpace(open) != "" && strings.TrimSpace(close) != "" {
+		// a delimiter that also closes the range can never open a nested one
+		s.add(open, ignoreRule{close: close, nested: nested && open != close})
+	}
+}
+
+func (s *ignoreSet) addToEOL(open string) {
+	if strings.TrimSpace(open) != "" {
+		s.add(open, ignoreRule{toEOL: true})
+	}
+}
+
+// clone returns a copy of s that can be changed without changing s.
+func (s *ignoreSet) clone() ignoreSet {
+	var c ignoreSet
+	for open, rule := range s.rules {
+		c.add(open, rule)
+	}
+
+	return c
+}
+
+// AddIgnoreTokens adds the list of tokens to be ignored when capturing tokens to be emitted.
+// This can be called at anytime during lexing to ignore certain tokens from being captured.
+func (lxr *Lexer) AddIgnoreTokens(tokens ...string) {
+	lxr.ignores.addTokens(tokens)
+}
+
+// AddIgnoreRange ignores everything from the open token up to and including the next close token,
+// e.g. AddIgnoreRange("/*", "*/", false) ignores whole block comments rather than just their delimiters.
+//
+// If nested is true, open tokens found within the range must each be closed before the range ends.
+// nested has no effect when open and close are the same, e.g. AddIgnoreRange("\"", "\"", true) ignores
+// up to the next quote. A range that is never closed is ignored up to the end of the input.
+//
+// This can be called at anytime during lexing and can be removed by passing open to RemoveIgnoreTokens.
+func (lxr *Lexer) AddIgnoreRange(open, close string, nested bool) {
+	lxr.ignores.addRange(open, close, nested)
+}
+
+// AddIgnoreToEOL ignores everything from the open token up to the end of the line, e.g.
+// AddIgnoreToEOL("//") ignores whole line comments. The newline itself is not ignored.
+//
+// This can be called at anytime during lexing and can be removed by passing open to RemoveIgnoreTokens.
+func (lxr *Lexer) AddIgnoreToEOL(open string) {
+	lxr.ignores.addToEOL(open)
+}
+
 // RemoveIgnoreTokens removes the list of tokens from the ignore list previously added with
-// AddIgnoreTokens.
+// AddIgnoreTokens, AddIgnoreRange or AddIgnoreToEOL.
 //
 // This can be called at anytime during lexing.
 func (lxr *Lexer) RemoveIgnoreTokens(tokens ...string) {
 	for _, tkn := range tokens {
-		if strings.TrimSpace(tkn) != "" {
-			lxr.ignoreTokens[tkn] = false
-		}
+		lxr.ignores.remove(tkn)
 	}
 }
 
@@ -86,69 +260,165 @@ func (lxr *Lexer) RemoveIgnoreTokens(tokens ...string) {
 // This function can be used for testing one-off lex functions or simple chains. This function should
 // not be used for building parser and instead consumers should use the NextEmittedToken function to
 // start/control processing of input
+//
+// Tokens emitted while running are queued and can still be retrieved with NextEmittedToken afterwards.
 func (lxr *Lexer) Run() {
-	for state := lxr.begin; state != nil; {
+	for state := lxr.begin; state != nil && !lxr.stopped; {
 		state = state(lxr)
+		lxr.reportInputErr()
 	}
 
-	lxr.shutdown()
+	lxr.state = nil
 }
 
-// NextEmittedToken returns the next Token that has been emitted by the lexer.
+// NextEmittedToken returns the next Token that has been emitted by the lexer, running the lexer until a
+// Token is emitted. Once the lexer is done, a Token with TokenTypeEOF is returned.
 //
-// This is the main function parsers should use in a loop until the end of input is reached.
+// Parsers that consume every token should prefer ranging over All.
 func (lxr *Lexer) NextEmittedToken() Token {
 	lxr.enterDebug("NextEmittedToken")
 	for {
-		select {
-		case token := <-lxr.tokens:
-			lxr.logDebug("sending token %+v", token)
+		if token, ok := lxr.tokens.pop(); ok {
+			lxr.logDebug("sending %s token %q", token.Type(), token.String())
 			lxr.exitDebug("NextEmittedToken")
 			return token
-		default:
-			if lxr.state != nil {
-				lxr.state = lxr.state(lxr)
-			} else {
-				_, _ = ioutil.ReadAll(lxr.inputBuffer)
-				lxr.currentRune = RuneEOF
-				lxr.logDebug("sending tokenEOF")
-				lxr.exitDebug("NextEmittedToken")
-				return defaultToken{tokenType: TokenTypeEOF, value: StringEOF}
-			}
 		}
+
+		if eof, done := lxr.step(); done {
+			lxr.logDebug("sending tokenEOF")
+			lxr.exitDebug("NextEmittedToken")
+			return eof
+		}
+	}
+}
+
+// step runs the next LexFn of the lexer, or returns the EOF token and true once the lexer is done.
+func (lxr *Lexer) step() (defaultToken, bool) {
+	if lxr.state != nil {
+		lxr.state = lxr.state(lxr)
+		lxr.reportInputErr()
+		if lxr.stopped {
+			lxr.state = nil
+		}
+		return defaultToken{}, false
+	}
+
+	if lxr.layoutLine > 0 {
+		lxr.flushLayout()
+		return defaultToken{}, false
+	}
+
+	if lxr.inputErrDue {
+		lxr.reportInputErr()
+		return defaultToken{}, false
+	}
+
+	lxr.recordRest()
+	if lxr.inputErr == nil {
+		if _, err := io.Copy(io.Discard, lxr.inputBuffer); err != nil {
+			lxr.inputErr = err
+		}
+		_ = lxr.Close()
+	}
+
+	// the LexFn chain may have ended before reading up to an error seen while peeking or draining
+	if lxr.inputErr != nil && lxr.inputErr != io.EOF && !lxr.inputErrSent {
+		lxr.inputErrSent = true
+		lxr.errorf(CodeInput, lxr.currentPos, "error reading input: %w", lxr.inputErr)
+		return defaultToken{}, false
 	}
 
+	lxr.runeCache = nil
+	lxr.currentRune = RuneEOF
+	lxr.currentSize = 0
+	lxr.eof = true
+
+	return defaultToken{
+		tokenType: TokenTypeEOF,
+		value:     StringEOF,
+		start:     lxr.currentPos,
+		end:       lxr.currentPos,
+		trivia:    lxr.takeEOFTrivia(),
+	}, true
 }
 
 // Emit creates a new Token of type tokeType whose value is the value of the current capture buffer.
 // The Token is emitted and a new capture buffer is started.
+//
+// The emitted Token implements PositionedToken and spans from the first to the last rune written to
+// the capture buffer.
 func (lxr *Lexer) Emit(tokenType TokenType) {
 	lxr.enterDebug("Emit")
-	lxr.logDebug("emitting token %s", lxr.tokenBuffer.String())
-	lxr.tokens <- defaultToken{tokenType: tokenType, value: lxr.tokenBuffer.String()}
-	lxr.tokenBuffer.Reset()
+	lxr.logDebug("emitting %s token %q", tokenType, lxr.tokenBuffer.String())
+	start, end := lxr.captureSpan()
+	if span, ok := lxr.captured(); ok {
+		lxr.emitLayout(span)
+	}
+	lxr.pushDefault(defaultToken{
+		tokenType: tokenType,
+		value:     lxr.tokenBuffer.String(),
+		start:     start,
+		end:       end,
+		trivia:    lxr.takeTrivia(start, end),
+		segments:  lxr.segments,
+	})
+	lxr.resetCapture()
 	lxr.exitDebug("Emit")
 }
 
 // EmitToken emits the provided token but does not clear the current capture buffer
 // This can be sed to emit custom tokens during lexing without upsetting the parsing flow
+//
+// If token implements Positioner, it's WithPosition method is called with the span of the current
+// capture buffer, or if it is empty with the span of the capture buffer last cleared by Flush, and the
+// returned Token is emitted instead. Likewise, if token implements TriviaReceiver
+// and PreserveTrivia is set, it's WithTrivia method is called with the token's trivia, and if token
+// implements SegmentReceiver, it's WithSegments method is called with the segments of the same span.
 func (lxr *Lexer) EmitToken(token Token) {
 	lxr.enterDebug("EmitToken")
-	lxr.tokens <- token
+	span, ok := lxr.emitSpan()
+	if ok {
+		lxr.emitLayout(span)
+	}
+	start, end := span.start, span.end
+	if p, ok := token.(Positioner); ok {
+		token = p.WithPosition(start, end)
+	}
+	if r, ok := token.(TriviaReceiver); ok && lxr.PreserveTrivia {
+		t := lxr.takeTrivia(start, end)
+		token = r.WithTrivia(t.leading, t.raw, t.trailing)
+	}
+	if r, ok := token.(SegmentReceiver); ok {
+		token = r.WithSegments(span.segments)
+	}
+	lxr.pushToken(token)
+	lxr.flushed = nil
 	lxr.exitDebug("EmitToken")
 }
 
 // Flush clears the current capture buffer and returns it's previously held value.
 // This can be used to get values to build custom tokens to be emitted by EmitToken
+//
+// The span of the cleared buffer is remembered, so a custom token emitted by the next EmitToken while the
+// capture buffer is still empty is positioned at the flushed input.
 func (lxr *Lexer) Flush() string {
 	lxr.enterDebug("Flush")
 	retVal := lxr.tokenBuffer.String()
-	lxr.tokenBuffer.Reset()
+	span, ok := lxr.captured()
+	lxr.resetCapture()
+	if ok {
+		lxr.flushed = &span
+	}
 	lxr.exitDebug("Flush")
 
 	return retVal
 }
 
+// Position returns the position of the current rune in the input.
+func (lxr *Lexer) Position() Position {
+	return lxr.currentPos
+}
+
 // CaptureUntil reads characters from the input buffer and writes them to the capture buffer stopping
 // when it reaches the until token and returns whether or not the until token was actually reached.
 //
@@ -172,13 +442,55 @@ func (lxr *Lexer) CaptureUntil(skipWhitespace bool, until string) bool {
 //
 // If skipWitespace is true, no whitespace will be written to the capture buffer.
 func (lxr *Lexer) CaptureUntilOneOf(skipWhitespace bool, tokens ...string) string {
+	return lxr.captureUntilOneOf(skipWhitespace, lxr.IgnoreCase, tokens)
+}
+
+// CaptureUntilOneOfFold does the same thing as CaptureUntilOneOf but always matches the until tokens
+// using Unicode simple case folding regardless of the lexer's IgnoreCase setting, e.g. "end" is found in
+// "begin x END".
+func (lxr *Lexer) CaptureUntilOneOfFold(skipWhitespace bool, tokens ...string) string {
+	return lxr.captureUntilOneOf(skipWhitespace, true, tokens)
+}
+
+func (lxr *Lexer) captureUntilOneOf(skipWhitespace, fold bool, tokens []string) string {
 	lxr.enterDebug("ReadUntilOneOf")
-	if len(tokens) < 1 || lxr.currentRune == RuneEOF {
+	if len(tokens) < 1 || lxr.eof {
 		lxr.exitDebug("ReadUntilOneOf")
 		return ""
 	}
 
 	lxr.logDebug("searching for tokens %q", tokens)
+	foundToken := lxr.captureUntilMatch(skipWhitespace, fold, func() string {
+		_, tkn := lxr.currentTokenIsOneOf(fold, tokens)
+		return tkn
+	})
+
+	lxr.exitDebug("ReadUntilOneOf")
+	return foundToken
+}
+
+// CaptureUntilIn does the same thing as CaptureUntilOneOf but looks for the tokens in set, always
+// finding the longest token in the set at each position.
+//
+// If skipWitespace is true, no whitespace will be written to the capture buffer.
+func (lxr *Lexer) CaptureUntilIn(skipWhitespace bool, set *TokenSet) string {
+	lxr.enterDebug("CaptureUntilIn")
+	if set == nil || set.keys.empty() || lxr.eof {
+		lxr.exitDebug("CaptureUntilIn")
+		return ""
+	}
+
+	foundToken := lxr.captureUntilMatch(skipWhitespace, lxr.IgnoreCase, func() string {
+		return lxr.matchTrie(&set.keys)
+	})
+
+	lxr.exitDebug("CaptureUntilIn")
+	return foundToken
+}
+
+// captureUntilMatch writes runes to the capture buffer until match returns a token and records that
+// token as the last known token. fold records whether match compared runes using case folding.
+func (lxr *Lexer) captureUntilMatch(skipWhitespace, fold bool, match func() string) string {
 	foundToken := ""
 
 	for {
@@ -189,7 +501,7 @@ func (lxr *Lexer) CaptureUntilOneOf(skipWhitespace bool, tokens ...string) strin
 
 		ch := lxr.currentRune
 		lxr.logDebug("testing char %q", ch)
-		if ch == RuneEOF {
+		if lxr.eof {
 			break
 		}
 
@@ -198,30 +510,18 @@ func (lxr *Lexer) CaptureUntilOneOf(skipWhitespace bool, tokens ...string) strin
 			continue
 		}
 
-		for _, tkn := range tokens {
-			if tkn == "" {
-				lxr.logDebug("skipping blank token")
-				continue
-			}
-
-			if lxr.CurrentTokenIs(tkn) {
-				lxr.logDebug("found token '%s'", tkn)
-				foundToken = tkn
-				break
-			}
-		}
-
-		if foundToken != "" {
+		if foundToken = match(); foundToken != "" {
+			lxr.logDebug("found token '%s'", foundToken)
 			break
 		}
 
 		lxr.logDebug("writing to buffer %q", ch)
-		lxr.tokenBuffer.WriteRune(ch)
+		lxr.capture()
 		lxr.read()
 	}
 
-	lxr.exitDebug("ReadUntilOneOf")
 	lxr.lastKnownToken = foundToken
+	lxr.lastKnownFold = fold
 
 	return foundToken
 }
@@ -229,75 +529,144 @@ func (lxr *Lexer) CaptureUntilOneOf(skipWhitespace bool, tokens ...string) strin
 // CaptureIdent reads all valide IDENT characters from the input stream and writes them to the capture
 // buffer stopping when a non-ident character is reached and returns whether an ident character was
 // indeed captured.
+//
+// Valid IDENT characters are defined by the lexer's IdentRule.
 func (lxr *Lexer) CaptureIdent() bool {
 	lxr.enterDebug("ReadIdent")
-	foundIdent := false
-	if lxr.AutoEatWhitespace {
-		lxr.EatWhitespace()
-	}
+	rule := lxr.identRule()
+	first := true
+	foundIdent := lxr.captureWhile(func(ch rune) bool {
+		if first {
+			first = false
+			return rule.Start(ch)
+		}
+		return rule.Continue(ch)
+	})
+	lxr.exitDebug("ReadIdent")
+	return foundIdent
+}
+
+// CaptureWhile reads characters from the input stream and writes them to the capture buffer for as long
+// as accept returns true and returns whether any characters were captured.
+//
+// Like CaptureIdent, whitespace before and after the captured characters is discarded if
+// AutoEatWhitespace is set and ignore tokens are skipped.
+func (lxr *Lexer) CaptureWhile(accept func(rune) bool) bool {
+	lxr.enterDebug("CaptureWhile")
+	found := lxr.captureWhile(accept)
+	lxr.exitDebug("CaptureWhile")
+	return found
+}
+
+// CaptureWhileIn does the same thing as CaptureWhile but accepts characters found in any of the given
+// unicode range tables, e.g. CaptureWhileIn(unicode.ASCII_Hex_Digit).
+func (lxr *Lexer) CaptureWhileIn(tables ...*unicode.RangeTable) bool {
+	lxr.enterDebug("CaptureWhileIn")
+	found := lxr.captureWhile(func(ch rune) bool {
+		return unicode.IsOneOf(tables, ch)
+	})
+	lxr.exitDebug("CaptureWhileIn")
+	return found
+}
+
+// CaptureUntilFunc reads characters from the input stream and writes them to the capture buffer
+// stopping when stop returns true and returns whether such a character was actually reached.
+//
+// Whitespace before the captured characters is discarded if AutoEatWhitespace is set and ignore tokens
+// are skipped. The character that stop returned true for becomes the last known token so it can be
+// handled with ConsumeCurrentToken or SkipCurrentToken.
+func (lxr *Lexer) CaptureUntilFunc(stop func(rune) bool) bool {
+	lxr.enterDebug("CaptureUntilFunc")
+	lxr.eatLeading()
+	found := lxr.captureUntilMatch(false, false, func() string {
+		if stop(lxr.currentRune) {
+			return string(lxr.currentRune)
+		}
+		return ""
+	}) != ""
+	lxr.exitDebug("CaptureUntilFunc")
+	return found
+}
+
+// captureWhile writes runes to the capture buffer for as long as accept returns true, skipping
+// surrounding whitespace and ignores the way CaptureIdent always has.
+func (lxr *Lexer) captureWhile(accept func(rune) bool) bool {
+	found := false
+	lxr.eatLeading()
 	for {
 
 		ch := lxr.currentRune
 		lxr.logDebug("currentRune is: %q", lxr.currentRune)
-		if ch == RuneEOF {
+		if lxr.eof {
 			lxr.logDebug("EOF, exiting")
 			break
 		}
 
 		if lxr.skipIgnores() {
-			if lxr.AutoEatWhitespace {
-				lxr.EatWhitespace()
-			}
+			lxr.eatLeading()
 			continue
 		}
 
-		if !unicode.IsLetter(ch) && !unicode.IsDigit(ch) && ch != '_' {
-			lxr.logDebug("not an ident character, exiting")
+		if !accept(ch) {
+			lxr.logDebug("not an accepted character, exiting")
 			break
 		}
 
-		foundIdent = true
+		found = true
 		lxr.logDebug("writing to buffer %q", ch)
-		lxr.tokenBuffer.WriteRune(ch)
+		lxr.capture()
 		lxr.read()
 
 	}
 
+	lxr.eatTrailing()
+	return found
+}
+
+// eatLeading discards whitespace if AutoEatWhitespace is set.
+func (lxr *Lexer) eatLeading() {
 	if lxr.AutoEatWhitespace {
 		lxr.EatWhitespace()
 	}
+}
 
-	if lxr.skipIgnores() && lxr.AutoEatWhitespace {
+// eatTrailing discards whitespace after a capture if AutoEatWhitespace is set along with a following
+// ignore token.
+func (lxr *Lexer) eatTrailing() {
+	if lxr.AutoEatWhitespace {
 		lxr.EatWhitespace()
 	}
 
-	lxr.exitDebug("ReadIdent")
-	return foundIdent
+	if lxr.skipIgnores() && lxr.AutoEatWhitespace {
+		lxr.EatWhitespace()
+	}
 }
 
-// ConsumeCurrentToken consumes the token found by a previous call to CaptureUntil or CaptureUntilOneOf
-// and writes it to the capture buffer returning whether or not a token was indeed consumed.
+// ConsumeCurrentToken consumes the token found by a previous call to CaptureUntil, CaptureUntilOneOf,
+// CaptureUntilIn or CurrentTokenIsIn and writes it to the capture buffer returning whether or not a
+// token was indeed consumed.
 //
 // If clearPrevious is true the previous buffer will be discarded and the token will be written to a
 // new buffer.
 //
+// The token is matched the same way it was found, so a token found case-insensitively is consumed
+// case-insensitively, and the original text of the input is written to the capture buffer.
+//
 // If no previous token was found this method will return false without clearing the buffer.
 func (lxr *Lexer) ConsumeCurrentToken(clearPrevious bool) bool {
-	if lxr.lastKnownToken == "" || !lxr.CurrentTokenIs(lxr.lastKnownToken) || lxr.currentRune == RuneEOF {
+	if lxr.lastKnownToken == "" || !lxr.currentTokenIsLastKnown() || lxr.eof {
 		return false
 	}
 
 	if clearPrevious {
-		lxr.tokenBuffer.Reset()
+		lxr.resetCapture()
 	}
 
-	lxr.tokenBuffer.WriteRune(lxr.currentRune)
-	numRunes := utf8.RuneCountInString(lxr.lastKnownToken) - 1
+	numRunes := utf8.RuneCountInString(lxr.lastKnownToken)
 	for i := 0; i < numRunes; i++ {
-		ch := lxr.read()
-		lxr.tokenBuffer.WriteRune(ch)
+		lxr.capture()
+		lxr.read()
 	}
-	lxr.read()
 
 	if lxr.AutoEatWhitespace {
 		lxr.EatWhitespace()
@@ -320,16 +689,16 @@ func (lxr *Lexer) ConsumeCurrentToken(clearPrevious bool) bool {
 func (lxr *Lexer) SkipCurrentToken(clearPrevious bool) bool {
 	lxr.enterDebug("Skip Current Token")
 	lxr.logDebug("checking lastKnowToken %q", lxr.lastKnownToken)
-	gotLastKnown := lxr.CurrentTokenIs(lxr.lastKnownToken)
+	gotLastKnown := lxr.currentTokenIsLastKnown()
 	lxr.logDebug("got lastKnownToken? %t", gotLastKnown)
 	lxr.logDebug("lastKnowToken %q", lxr.lastKnownToken)
-	if lxr.lastKnownToken == "" || !gotLastKnown || lxr.currentRune == RuneEOF {
+	if lxr.lastKnownToken == "" || !gotLastKnown || lxr.eof {
 		lxr.logDebug("last known token not found, returning")
 		return false
 	}
 
 	if clearPrevious {
-		lxr.tokenBuffer.Reset()
+		lxr.resetCapture()
 	}
 
 	numRunes := utf8.RuneCountInString(lxr.lastKnownToken)
@@ -357,12 +726,62 @@ func (lxr *Lexer) CurrentTokenIs(t string) bool {
 	return found
 }
 
-// CurrentTokenIsOneOf returns whether the start of the current input stream buffer is on t.
+// CurrentTokenIsFold does the same thing as CurrentTokenIs but always compares runes using Unicode
+// simple case folding regardless of the lexer's IgnoreCase setting.
+func (lxr *Lexer) CurrentTokenIsFold(t string) bool {
+	found, _ := lxr.CurrentTokenIsOneOfFold(t)
+	return found
+}
+
+// currentTokenIsLastKnown returns whether the current input stream buffer is on the last known token,
+// matching it the same way it was found.
+func (lxr *Lexer) currentTokenIsLastKnown() bool {
+	found, _ := lxr.currentTokenIsOneOf(lxr.lastKnownFold, []string{lxr.lastKnownToken})
+	return found
+}
+
+// CurrentTokenIsIn returns whether the start of the current input stream buffer is on one of the
+// tokens in set and the longest such token.
+//
+// The found token becomes the last known token used by ConsumeCurrentToken and SkipCurrentToken.
+func (lxr *Lexer) CurrentTokenIsIn(set *TokenSet) (bool, string) {
+	if set == nil {
+		return false, ""
+	}
+
+	found := lxr.matchTrie(&set.keys)
+	if found != "" {
+		lxr.lastKnownToken = found
+		lxr.lastKnownFold = lxr.IgnoreCase
+	}
+
+	return found != "", found
+}
+
+// CurrentTokenIsOneOf returns whether the start of the current input stream buffer is on one of tokens
+// and the token that was found.
+//
+// If LongestMatch is set on the lexer the longest matching token is returned, otherwise the first
+// matching token in argument order is returned. If IgnoreCase is set on the lexer runes are compared
+// using Unicode simple case folding and the token is returned as it was given, not as it appears in the
+// input.
 func (lxr *Lexer) CurrentTokenIsOneOf(tokens ...string) (bool, string) {
+	return lxr.currentTokenIsOneOf(lxr.IgnoreCase, tokens)
+}
+
+// CurrentTokenIsOneOfFold does the same thing as CurrentTokenIsOneOf but always compares runes using
+// Unicode simple case folding regardless of the lexer's IgnoreCase setting, e.g. "select" is found at
+// "SELECT *".
+func (lxr *Lexer) CurrentTokenIsOneOfFold(tokens ...string) (bool, string) {
+	return lxr.currentTokenIsOneOf(true, tokens)
+}
+
+func (lxr *Lexer) currentTokenIsOneOf(fold bool, tokens []string) (bool, string) {
 	lxr.enterDebug("CurrentTokenIsOneOf")
 	found := ""
 
-	if lxr.currentRune == RuneEOF {
+	if lxr.eof {
+		lxr.exitDebug("CurrentTokenIsOneOf")
 		return false, ""
 	}
 
@@ -376,7 +795,7 @@ func (lxr *Lexer) CurrentTokenIsOneOf(tokens ...string) (bool, string) {
 		bufRunes := []rune{lxr.currentRune}
 		tokenRunes := []rune(tkn)
 
-		if tokenRunes[0] != bufRunes[0] {
+		if !runesEqual(fold, tokenRunes[0], bufRunes[0]) {
 			continue
 		}
 
@@ -389,10 +808,16 @@ func (lxr *Lexer) CurrentTokenIsOneOf(tokens ...string) (bool, string) {
 		}
 		lxr.logDebug("token runes %q", tokenRunes)
 		lxr.logDebug("buffer runes %q", bufRunes)
-		if reflect.DeepEqual(tokenRunes, bufRunes) {
+		if runeSlicesEqual(fold, tokenRunes, bufRunes) {
 			lxr.logDebug("rune slices match!")
-			found = tkn
-			break
+			if !lxr.LongestMatch {
+				found = tkn
+				break
+			}
+
+			if len(tokenRunes) > utf8.RuneCountInString(found) {
+				found = tkn
+			}
 		}
 	}
 
@@ -404,18 +829,24 @@ func (lxr *Lexer) CurrentTokenIsOneOf(tokens ...string) (bool, string) {
 
 // Errorf formats a string using format and args and emits a Token with TokenTypeError as it's type and
 // the formatted string as it's Value
+//
+// The emitted Token implements PositionedToken and is positioned at the current rune. It also
+// implements ErrorToken reporting a *LexError with CodeNone. Like fmt.Errorf, an error passed for a %w
+// verb becomes the cause of the LexError.
+//
+// Errorf returns nil which ends the LexFn chain, unless Recovery is set on the lexer in which case a LexFn
+// that recovers from the error as configured is returned.
 func (lxr *Lexer) Errorf(format string, args ...interface{}) LexFn {
-	lxr.tokens <- defaultToken{
-		tokenType: TokenTypeError,
-		value:     fmt.Sprintf(format, args...),
-	}
-
-	return nil
+	lxr.errorf(CodeNone, lxr.currentPos, format, args...)
+	return lxr.recoveryFn()
 }
 
 // IsEOF returns the true/false if the lexer is at the end of the input stream.
+//
+// Tokens emitted before the end of the input was reached may still be waiting to be returned by
+// NextEmittedToken, so parsers should not use IsEOF to end their loop. See All.
 func (lxr *Lexer) IsEOF() bool {
-	return lxr.currentRune == RuneEOF
+	return lxr.eof
 }
 
 // EatWhitespace is effectively an LTrim in that it reads and discards all whitespace starting with
@@ -430,7 +861,7 @@ func (lxr *Lexer) EatWhitespace() bool {
 
 	for {
 		lxr.read()
-		if lxr.currentRune == RuneEOF {
+		if lxr.eof {
 			return false
 		} else if !unicode.IsSpace(lxr.currentRune) {
 			break
@@ -440,79 +871,305 @@ func (lxr *Lexer) EatWhitespace() bool {
 	return true
 }
 
-func (lxr *Lexer) shutdown() {
-	close(lxr.tokens)
+// Close closes the underlying input if the lexer was created with NewLexerFromFile.
+//
+// This is called automatically when the end of the input is reached and only needs to be called by
+// consumers that stop lexing early. Calling Close more than once is a no-op.
+func (lxr *Lexer) Close() error {
+	if lxr.inputCloser == nil {
+		return nil
+	}
+
+	c := lxr.inputCloser
+	lxr.inputCloser = nil
+	return c.Close()
+}
+
+// readInput reads the next rune from the input buffer. Once the input returns an error, that error is
+// remembered and returned for every subsequent call so that an error seen while peeking is not lost.
+func (lxr *Lexer) readInput() (cachedRune, error) {
+	if lxr.inputErr != nil {
+		return cachedRune{}, lxr.inputErr
+	}
+
+	ch, size, err := lxr.inputBuffer.ReadRune()
+	if err != nil {
+		lxr.logDebug("input ended: %s", err)
+		lxr.inputErr = err
+		_ = lxr.Close()
+		return cachedRune{}, err
+	}
+
+	cr := cachedRune{ch: ch, size: size}
+	if ch == utf8.RuneError && size == 1 {
+		// keep the invalid byte so the input can be reproduced as trivia
+		_ = lxr.inputBuffer.UnreadRune()
+		cr.raw, _ = lxr.inputBuffer.ReadByte()
+	}
+
+	return cr, nil
 }
 
+// read consumes the current rune and makes the next rune in the input the current rune.
 func (lxr *Lexer) read() rune {
+	if lxr.markDepth > 0 && !lxr.eof {
+		lxr.history = append(lxr.history, lxr.current())
+	}
+
+	lxr.recordRune()
 
-	var ch rune
+	if lxr.Indentation != nil && !lxr.eof {
+		lxr.lineIndent.advance(lxr.currentRune, lxr.Indentation.tabWidth())
+	}
+
+	lxr.currentPos = lxr.nextPos()
+	return lxr.fill()
+}
+
+// fill loads the next rune from the rune cache or the input as the current rune without moving the
+// current position.
+func (lxr *Lexer) fill() rune {
 	if len(lxr.runeCache) > 0 {
-		ch = lxr.runeCache[0]
+		cr := lxr.runeCache[0]
 		lxr.runeCache = lxr.runeCache[1:]
-		lxr.currentRune = ch
-		return ch
+		lxr.currentRune = cr.ch
+		lxr.currentSize = cr.size
+		lxr.currentRaw = cr.raw
+		lxr.eof = false
+		return cr.ch
 	}
 
-	ch, _, err := lxr.inputBuffer.ReadRune()
+	cr, err := lxr.readInput()
 	if err != nil {
+		if err != io.EOF && !lxr.inputErrSent {
+			lxr.inputErrSent = true
+			lxr.inputErrDue = true
+			lxr.inputErrPos = lxr.currentPos
+		}
 		lxr.currentRune = RuneEOF
+		lxr.currentSize = 0
+		lxr.eof = true
 		return RuneEOF
 	}
 
-	lxr.currentRune = ch
-	return ch
+	lxr.currentRune = cr.ch
+	lxr.currentSize = cr.size
+	lxr.currentRaw = cr.raw
+	lxr.eof = false
+	return cr.ch
+}
+
+// reportInputErr reports an error returned by the reader of the input once the LexFn that ran into it
+// has returned, so that it follows the tokens emitted from the input read before it.
+func (lxr *Lexer) reportInputErr() {
+	if !lxr.inputErrDue {
+		return
+	}
+
+	lxr.inputErrDue = false
+	lxr.errorf(CodeInput, lxr.inputErrPos, "error reading input: %w", lxr.inputErr)
+}
+
+// current returns the current rune as a cachedRune.
+func (lxr *Lexer) current() cachedRune {
+	return cachedRune{ch: lxr.currentRune, size: lxr.currentSize, raw: lxr.currentRaw}
+}
+
+// nextPos returns the position directly following the current rune.
+func (lxr *Lexer) nextPos() Position {
+	pos := lxr.currentPos
+	if lxr.currentSize == 0 {
+		return pos
+	}
+
+	pos.Offset += lxr.currentSize
+	if lxr.currentRune == '\n' {
+		pos.Line++
+		pos.Column = 1
+	} else {
+		pos.Column++
+	}
+
+	return pos
 }
 
 func (lxr *Lexer) peek(numRunes int) []rune {
-	var readBuf []rune
 	var peekbuf []rune
-	var ch rune
-	var err error
 
-	for i := 0; i < numRunes; i++ {
-		if len(lxr.runeCache) > 0 {
-			ch = lxr.runeCache[0]
-			lxr.runeCache = lxr.runeCache[1:]
-			err = nil
-		} else {
-			ch, _, err = lxr.inputBuffer.ReadRune()
-		}
-		if err == nil {
-			readBuf = append(readBuf, ch)
-			peekbuf = append(peekbuf, ch)
+	for i := len(lxr.runeCache); i < numRunes; i++ {
+		cr, err := lxr.readInput()
+		if err != nil {
+			break
 		}
+		lxr.runeCache = append(lxr.runeCache, cr)
 	}
 
-	lxr.runeCache = append(lxr.runeCache, readBuf...)
+	for i := 0; i < numRunes && i < len(lxr.runeCache); i++ {
+		peekbuf = append(peekbuf, lxr.runeCache[i].ch)
+	}
 
 	return peekbuf
 }
 
+// capture writes the current rune to the capture buffer and extends the captured span to include it.
+func (lxr *Lexer) capture() {
+	if !lxr.captureStarted {
+		lxr.captureStarted = true
+		lxr.captureStart = lxr.currentPos
+		lxr.captureIndent = lxr.lineIndent
+	}
+
+	n, _ := lxr.tokenBuffer.WriteRune(lxr.currentRune)
+	lxr.captureEnd = lxr.nextPos()
+	lxr.addSegment(lxr.currentPos, lxr.captureEnd, n, lxr.currentRune == utf8.RuneError && lxr.currentSize == 1)
+}
+
+// captureRunes captures the next numRunes runes.
+func (lxr *Lexer) captureRunes(numRunes int) {
+	for i := 0; i < numRunes && !lxr.eof; i++ {
+		lxr.capture()
+		lxr.read()
+	}
+}
+
+// captureString writes s to the capture buffer in place of the input that was read since start, e.g. a
+// decoded escape sequence, and extends the captured span to include that input.
+func (lxr *Lexer) captureString(s string, start Position) {
+	if !lxr.captureStarted {
+		lxr.captureStarted = true
+		lxr.captureStart = start
+		lxr.captureIndent = lxr.lineIndent
+	}
+
+	lxr.tokenBuffer.WriteString(s)
+	lxr.captureEnd = lxr.currentPos
+	if s != "" {
+		lxr.addSegment(start, lxr.currentPos, len(s), true)
+	}
+}
+
+// resetCapture clears the capture buffer and it's captured span and segments.
+func (lxr *Lexer) resetCapture() {
+	lxr.tokenBuffer.Reset()
+	lxr.captureStarted = false
+	lxr.segments = nil
+	lxr.flushed = nil
+}
+
+// captureSpan returns the start and end positions of the capture buffer. If nothing has been captured
+// both positions are the position of the current rune.
+func (lxr *Lexer) captureSpan() (Position, Position) {
+	if !lxr.captureStarted {
+		return lxr.currentPos, lxr.currentPos
+	}
+
+	return lxr.captureStart, lxr.captureEnd
+}
+
+// capturedSpan is the span of input a capture buffer was captured from, the indentation of the line it
+// starts on and its segments.
+type capturedSpan struct {
+	start    Position
+	end      Position
+	indent   lineIndent
+	segments []Segment
+}
+
+// captured returns the span of the capture buffer and whether anything has been captured.
+func (lxr *Lexer) captured() (capturedSpan, bool) {
+	start, end := lxr.captureSpan()
+	span := capturedSpan{start: start, end: end, indent: lxr.captureIndent, segments: lxr.segments}
+	return span, lxr.captureStarted
+}
+
+// emitSpan returns the span of a custom token passed to EmitToken or EmitValue, which is the span of the
+// capture buffer or if it is empty the span of the capture buffer last cleared by Flush. If neither
+// holds any input, the span is empty at the current rune and false is returned.
+func (lxr *Lexer) emitSpan() (capturedSpan, bool) {
+	if !lxr.captureStarted && lxr.flushed != nil {
+		return *lxr.flushed, true
+	}
+
+	return lxr.captured()
+}
+
 func (lxr *Lexer) skipIgnores() bool {
-	if lxr.currentRune == RuneEOF {
+	if lxr.eof {
 		return false
 	}
 
 	lxr.enterDebug("skipIgnores")
-	foundIgnore := false
-	for ignore, doit := range lxr.ignoreTokens {
-		lxr.logDebug("testing ignore: %s", ignore)
-		if doit && lxr.CurrentTokenIs(ignore) {
-			lxr.logDebug("ignoring: %s", ignore)
-			foundIgnore = true
-			numRunes := utf8.RuneCountInString(ignore)
-
-			for i := 0; i < numRunes; i++ {
-				ch := lxr.read()
-				lxr.logDebug("read char: %q", ch)
+	ignore := lxr.matchTrie(&lxr.ignores.keys)
+	if ignore == "" {
+		lxr.exitDebug("skipIgnores")
+		return false
+	}
+
+	lxr.logDebug("ignoring: %s", ignore)
+	rule := lxr.ignores.rules[ignore]
+	lxr.skipRunes(utf8.RuneCountInString(ignore))
+
+	if rule.toEOL {
+		lxr.skipToEOL()
+	} else if rule.close != "" {
+		lxr.skipRange(ignore, rule)
+	}
+
+	lxr.exitDebug("skipIgnores")
+	return true
+}
+
+// matchTrie returns the longest key in t found at the current position or "" if there is none, folding
+// case if IgnoreCase is set.
+func (lxr *Lexer) matchTrie(t *trie) string {
+	if lxr.eof || t.empty() {
+		return ""
+	}
+
+	runes := append([]rune{lxr.currentRune}, lxr.peek(t.maxLen-1)...)
+	if lxr.IgnoreCase {
+		return t.longestFoldMatch(runes)
+	}
+
+	return t.longestMatch(runes)
+}
+
+// skipRange discards everything up to and including the close token of rule. The open token is
+// expected to already be consumed.
+func (lxr *Lexer) skipRange(open string, rule ignoreRule) {
+	depth := 1
+	for !lxr.eof {
+		if rule.nested && lxr.CurrentTokenIs(open) {
+			lxr.skipRunes(utf8.RuneCountInString(open))
+			depth++
+			continue
+		}
+
+		if lxr.CurrentTokenIs(rule.close) {
+			lxr.skipRunes(utf8.RuneCountInString(rule.close))
+			depth--
+			if depth == 0 {
+				return
 			}
-			break
+			continue
 		}
+
+		lxr.read()
+	}
+}
+
+// skipToEOL discards everything up to but not including the next newline.
+func (lxr *Lexer) skipToEOL() {
+	for !lxr.eof && lxr.currentRune != '\n' {
+		lxr.read()
 	}
+}
 
-	lxr.exitDebug("skipIgnores")
-	return foundIgnore
+func (lxr *Lexer) skipRunes(numRunes int) {
+	for i := 0; i < numRunes; i++ {
+		ch := lxr.read()
+		lxr.logDebug("read char: %q", ch)
+	}
 }
 
 func (lxr *Lexer) enterDebug(format string, a ...interface{}) {
@@ -1,8 +1,15 @@
 package goblex_test
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"unicode"
 
 	"github.com/brainicorn/goblex"
 
@@ -705,3 +712,723 @@ func hashtaglex(lexer *goblex.Lexer, end []string) goblex.LexFn {
 
 	return nil
 }
+
+type errAfterReader struct {
+	data string
+	err  error
+}
+
+func (r *errAfterReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, r.err
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func (suite *GoblexTestSuite) TestReaderShortReads() {
+	suite.T().Parallel()
+
+	suite.lexHashtagReader(iotest.OneByteReader(strings.NewReader("I love #unicorns yup")), hashtagWS)
+}
+
+func (suite *GoblexTestSuite) TestReaderHalfReads() {
+	suite.T().Parallel()
+
+	suite.lexHashtagReader(iotest.HalfReader(strings.NewReader("I love #unicorns yup")), hashtagWS)
+}
+
+func (suite *GoblexTestSuite) TestReaderMultiByteShortReads() {
+	suite.T().Parallel()
+	var token goblex.Token
+	tkn := ""
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureUntil(true, "!")
+		lexer.Emit(basicTokenType)
+		return nil
+	}
+
+	l := goblex.NewLexerFromReader("simple", iotest.OneByteReader(strings.NewReader("I ♥ 🦄!")), lexFun)
+	for {
+		token = l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		if token.Type() == basicTokenType {
+			tkn = token.String()
+		}
+	}
+
+	assert.Equal(suite.T(), "I♥🦄", tkn)
+}
+
+func (suite *GoblexTestSuite) TestReaderError() {
+	suite.T().Parallel()
+	var token goblex.Token
+	var types []goblex.TokenType
+	var values []string
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureUntil(true, "!")
+		lexer.Emit(basicTokenType)
+		return nil
+	}
+
+	r := &errAfterReader{data: "I love unicorns", err: errors.New("disk on fire")}
+	l := goblex.NewLexerFromReader("simple", r, lexFun)
+	for {
+		token = l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		types = append(types, token.Type())
+		values = append(values, token.String())
+	}
+
+	assert.Equal(suite.T(), []goblex.TokenType{basicTokenType, goblex.TokenTypeError}, types)
+	assert.Equal(suite.T(), "Iloveunicorns", values[0])
+	assert.Contains(suite.T(), values[1], "disk on fire")
+}
+
+func (suite *GoblexTestSuite) TestReaderErrorOnFirstRead() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexerFromReader("simple", iotest.ErrReader(errors.New("nope")), lexFun)
+
+	assert.True(suite.T(), l.IsEOF())
+
+	token := l.NextEmittedToken()
+	assert.Equal(suite.T(), goblex.TokenTypeError, token.Type())
+	assert.Contains(suite.T(), token.String(), "nope")
+
+	token = l.NextEmittedToken()
+	assert.Equal(suite.T(), goblex.TokenTypeEOF, token.Type())
+}
+
+func (suite *GoblexTestSuite) TestReaderErrorAfterTokens() {
+	suite.T().Parallel()
+
+	var lexFun goblex.LexFn
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if !lexer.CaptureIdent() {
+			return nil
+		}
+
+		lexer.Emit(basicTokenType)
+		return lexFun
+	}
+
+	l := goblex.NewLexerFromReader("simple", iotest.TimeoutReader(strings.NewReader("abc def")), lexFun)
+
+	var values []string
+	for token := range l.All() {
+		pt := token.(goblex.PositionedToken)
+		values = append(values, fmt.Sprintf("%s %s-%s", token.Type(), pt.Start(), pt.End()))
+	}
+
+	assert.Equal(suite.T(), []string{
+		"TokenType(0) 1:1-1:4", "TokenType(0) 1:5-1:8", "ERROR 1:8-1:8", "EOF 1:8-1:8",
+	}, values)
+}
+
+func (suite *GoblexTestSuite) TestReaderErrorWhilePeeking() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CurrentTokenIs("abc")
+		return nil
+	}
+
+	r := &errAfterReader{data: "ab", err: errors.New("disk on fire")}
+	l := goblex.NewLexerFromReader("simple", r, lexFun)
+
+	var types []goblex.TokenType
+	for token := range l.All() {
+		types = append(types, token.Type())
+	}
+
+	assert.Equal(suite.T(), []goblex.TokenType{goblex.TokenTypeError, goblex.TokenTypeEOF}, types)
+	suite.Require().Error(l.Err())
+	assert.Contains(suite.T(), l.Err().Error(), "disk on fire")
+
+	var lexErr *goblex.LexError
+	suite.Require().True(errors.As(l.Err(), &lexErr))
+	assert.Equal(suite.T(), goblex.CodeInput, lexErr.Code)
+}
+
+func (suite *GoblexTestSuite) TestReaderErrorAfterEarlyReturn() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	r := &errAfterReader{data: "I love unicorns", err: errors.New("disk on fire")}
+	l := goblex.NewLexerFromReader("simple", r, lexFun)
+
+	var types []goblex.TokenType
+	for token := range l.All() {
+		types = append(types, token.Type())
+	}
+
+	assert.Equal(suite.T(), []goblex.TokenType{goblex.TokenTypeError, goblex.TokenTypeEOF}, types)
+	suite.Require().Error(l.Err())
+	assert.Contains(suite.T(), l.Err().Error(), "disk on fire")
+}
+
+func (suite *GoblexTestSuite) TestLexerFromFile() {
+	suite.T().Parallel()
+
+	path := filepath.Join(suite.T().TempDir(), "unicorns.txt")
+	err := os.WriteFile(path, []byte(unicornInputSpace), 0600)
+	suite.Require().NoError(err)
+
+	l, err := goblex.NewLexerFromFile("simple", path, hashtagWS)
+	suite.Require().NoError(err)
+
+	hashtag := "nada"
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		if token.Type() == basicTokenType {
+			hashtag = token.String()
+		}
+	}
+
+	assert.Equal(suite.T(), "#unicorns", hashtag)
+	assert.NoError(suite.T(), l.Close())
+}
+
+func (suite *GoblexTestSuite) TestLexerFromMissingFile() {
+	suite.T().Parallel()
+
+	l, err := goblex.NewLexerFromFile("simple", filepath.Join(suite.T().TempDir(), "nope.txt"), hashtagWS)
+
+	assert.Nil(suite.T(), l)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *GoblexTestSuite) lexHashtagReader(r io.Reader, lexFun goblex.LexFn) {
+	l := goblex.NewLexerFromReader("simple", r, lexFun)
+
+	hashtag := "nada"
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		if token.Type() == basicTokenType {
+			hashtag = token.String()
+		}
+	}
+
+	assert.Equal(suite.T(), "#unicorns", hashtag, "expected #unicorns but was %s", hashtag)
+}
+
+type positionedSliceToken struct {
+	value      string
+	start, end goblex.Position
+}
+
+func (t positionedSliceToken) Type() goblex.TokenType {
+	return basicTokenType
+}
+
+func (t positionedSliceToken) String() string {
+	return t.value
+}
+
+func (t positionedSliceToken) Start() goblex.Position {
+	return t.start
+}
+
+func (t positionedSliceToken) End() goblex.Position {
+	return t.end
+}
+
+func (t positionedSliceToken) WithPosition(start, end goblex.Position) goblex.Token {
+	t.start = start
+	t.end = end
+	return t
+}
+
+func (suite *GoblexTestSuite) TestEmitPositions() {
+	suite.T().Parallel()
+	var tokens []goblex.PositionedToken
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.CaptureUntil(true, "#") {
+			lexer.ConsumeCurrentToken(true)
+			lexer.CaptureIdent()
+			lexer.Emit(basicTokenType)
+			return lexFun
+		}
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "I ♥ #unicorns\nand\n  #cheese", lexFun)
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		tokens = append(tokens, token.(goblex.PositionedToken))
+	}
+
+	suite.Require().Len(tokens, 2)
+	assert.Equal(suite.T(), "#unicorns", tokens[0].String())
+	assert.Equal(suite.T(), goblex.Position{Offset: 6, Line: 1, Column: 5}, tokens[0].Start())
+	assert.Equal(suite.T(), goblex.Position{Offset: 15, Line: 1, Column: 14}, tokens[0].End())
+	assert.Equal(suite.T(), "#cheese", tokens[1].String())
+	assert.Equal(suite.T(), goblex.Position{Offset: 22, Line: 3, Column: 3}, tokens[1].Start())
+	assert.Equal(suite.T(), goblex.Position{Offset: 29, Line: 3, Column: 10}, tokens[1].End())
+	assert.Equal(suite.T(), "3:3", tokens[1].Start().String())
+}
+
+func (suite *GoblexTestSuite) TestErrorfPosition() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureUntil(true, "!")
+		return lexer.Errorf("unexpected %q", "!")
+	}
+
+	l := goblex.NewLexer("simple", "line one\nline two!", lexFun)
+	token := l.NextEmittedToken()
+
+	suite.Require().Equal(goblex.TokenTypeError, token.Type())
+	pt := token.(goblex.PositionedToken)
+	assert.Equal(suite.T(), goblex.Position{Offset: 17, Line: 2, Column: 9}, pt.Start())
+	assert.Equal(suite.T(), pt.Start(), pt.End())
+}
+
+func (suite *GoblexTestSuite) TestEmitTokenPositioner() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureIdent()
+		lexer.EmitToken(positionedSliceToken{value: lexer.Flush()})
+		lexer.CaptureIdent()
+		lexer.EmitToken(positionedSliceToken{value: "custom"})
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "some text", lexFun)
+
+	first := l.NextEmittedToken().(positionedSliceToken)
+	assert.Equal(suite.T(), "some", first.value)
+	assert.Equal(suite.T(), goblex.Position{Offset: 0, Line: 1, Column: 1}, first.start)
+	assert.Equal(suite.T(), goblex.Position{Offset: 4, Line: 1, Column: 5}, first.end)
+
+	second := l.NextEmittedToken().(positionedSliceToken)
+	assert.Equal(suite.T(), goblex.Position{Offset: 5, Line: 1, Column: 6}, second.start)
+	assert.Equal(suite.T(), goblex.Position{Offset: 9, Line: 1, Column: 10}, second.end)
+}
+
+func (suite *GoblexTestSuite) TestEmitTokenAfterFlush() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		for lexer.CaptureIdent() {
+			lexer.EmitToken(positionedSliceToken{value: lexer.Flush()})
+		}
+		lexer.EmitToken(positionedSliceToken{value: "end"})
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "ab\n  cd", lexFun)
+
+	var spans []string
+	for token := range l.All() {
+		if pt, ok := token.(positionedSliceToken); ok {
+			spans = append(spans, fmt.Sprintf("%s %s-%s", pt.value, pt.start, pt.end))
+		}
+	}
+
+	assert.Equal(suite.T(), []string{"ab 1:1-1:3", "cd 2:3-2:5", "end 2:5-2:5"}, spans)
+}
+
+func (suite *GoblexTestSuite) TestEmitBurst() {
+	suite.T().Parallel()
+	var values []string
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		for i := 0; i < 1000; i++ {
+			lexer.EmitToken(positionedSliceToken{value: fmt.Sprintf("%d", i)})
+		}
+		lexer.Errorf("done")
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "", lexFun)
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		values = append(values, token.String())
+	}
+
+	suite.Require().Len(values, 1001)
+	for i := 0; i < 1000; i++ {
+		assert.Equal(suite.T(), fmt.Sprintf("%d", i), values[i])
+	}
+	assert.Equal(suite.T(), "done", values[1000])
+}
+
+func (suite *GoblexTestSuite) TestEmitBurstAcrossSteps() {
+	suite.T().Parallel()
+	var values []string
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if !lexer.CaptureIdent() {
+			return nil
+		}
+
+		word := lexer.Flush()
+		for i := 0; i < 4; i++ {
+			lexer.EmitToken(positionedSliceToken{value: fmt.Sprintf("%s%d", word, i)})
+		}
+		return lexFun
+	}
+
+	l := goblex.NewLexer("simple", "a b c", lexFun)
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		values = append(values, token.String())
+	}
+
+	expected := []string{"a0", "a1", "a2", "a3", "b0", "b1", "b2", "b3", "c0", "c1", "c2", "c3"}
+	assert.Equal(suite.T(), expected, values)
+}
+
+func (suite *GoblexTestSuite) TestRunWithoutConsumer() {
+	suite.T().Parallel()
+	var values []string
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.CaptureIdent() {
+			lexer.Emit(basicTokenType)
+			return lexFun
+		}
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "one two three four five six", lexFun)
+	l.Run()
+
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		values = append(values, token.String())
+	}
+
+	assert.Equal(suite.T(), []string{"one", "two", "three", "four", "five", "six"}, values)
+}
+
+func (suite *GoblexTestSuite) TestNULIsNotEOF() {
+	suite.T().Parallel()
+	var values []string
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.CaptureUntilOneOf(false, "|", "!") != "" {
+			lexer.Emit(basicTokenType)
+			lexer.SkipCurrentToken(true)
+			return lexFun
+		}
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "a\x00b|\x00|c \x00 d!", lexFun)
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		values = append(values, token.String())
+	}
+
+	assert.Equal(suite.T(), []string{"a\x00b", "\x00", "c \x00 d"}, values)
+}
+
+func (suite *GoblexTestSuite) TestNULIdentAndWhitespace() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "  \x00abc", lexFun)
+
+	assert.True(suite.T(), l.EatWhitespace())
+	assert.False(suite.T(), l.IsEOF())
+	assert.True(suite.T(), l.CurrentTokenIs("\x00a"))
+	assert.False(suite.T(), l.CaptureIdent())
+	assert.True(suite.T(), l.CaptureUntil(false, "c"))
+	assert.Equal(suite.T(), "\x00ab", l.Flush())
+	assert.True(suite.T(), l.SkipCurrentToken(false))
+	assert.True(suite.T(), l.IsEOF())
+}
+
+func (suite *GoblexTestSuite) lexUntilBracket(input string, setup func(l *goblex.Lexer)) []string {
+	var values []string
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.CaptureUntil(true, "]") {
+			lexer.Emit(basicTokenType)
+			lexer.SkipCurrentToken(true)
+			return lexFun
+		}
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", input, lexFun)
+	setup(l)
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		values = append(values, token.String())
+	}
+
+	return values
+}
+
+func (suite *GoblexTestSuite) TestIgnoreRangeAndEOL() {
+	suite.T().Parallel()
+
+	input := `ident /* a ] in a comment */ more // a ] in a line comment
+	end] next]`
+
+	values := suite.lexUntilBracket(input, func(l *goblex.Lexer) {
+		l.AddIgnoreRange("/*", "*/", false)
+		l.AddIgnoreToEOL("//")
+	})
+
+	assert.Equal(suite.T(), []string{"identmoreend", "next"}, values)
+}
+
+func (suite *GoblexTestSuite) TestIgnoreToEOLKeepsNewline() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureUntil(false, "]")
+		lexer.Emit(basicTokenType)
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "a # comment ]\nb]", lexFun)
+	l.AddIgnoreToEOL("#")
+
+	assert.Equal(suite.T(), "a \nb", l.NextEmittedToken().String())
+}
+
+func (suite *GoblexTestSuite) TestIgnoreNestedRange() {
+	suite.T().Parallel()
+
+	input := "/* a /* b ] */ c ] */x]"
+
+	nested := suite.lexUntilBracket(input, func(l *goblex.Lexer) {
+		l.AddIgnoreRange("/*", "*/", true)
+	})
+	flat := suite.lexUntilBracket(input, func(l *goblex.Lexer) {
+		l.AddIgnoreRange("/*", "*/", false)
+	})
+
+	assert.Equal(suite.T(), []string{"x"}, nested)
+	assert.Equal(suite.T(), []string{"c", "*/x"}, flat)
+}
+
+func (suite *GoblexTestSuite) TestIgnoreNestedRangeSameDelimiters() {
+	suite.T().Parallel()
+
+	values := suite.lexUntilBracket(`a "b] c" d] e]`, func(l *goblex.Lexer) {
+		l.AddIgnoreRange(`"`, `"`, true)
+	})
+
+	assert.Equal(suite.T(), []string{"ad", "e"}, values)
+}
+
+func (suite *GoblexTestSuite) TestIgnoreUnclosedRange() {
+	suite.T().Parallel()
+
+	values := suite.lexUntilBracket("a] b /* c] d]", func(l *goblex.Lexer) {
+		l.AddIgnoreRange("/*", "*/", false)
+	})
+
+	assert.Equal(suite.T(), []string{"a"}, values)
+}
+
+func (suite *GoblexTestSuite) TestIgnoreRangeIdentAndConsume() {
+	suite.T().Parallel()
+	var values []string
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureIdent()
+		values = append(values, lexer.Flush())
+		lexer.CaptureUntil(true, "{{")
+		lexer.Flush()
+		lexer.ConsumeCurrentToken(true)
+		values = append(values, lexer.Flush())
+		lexer.CaptureIdent()
+		values = append(values, lexer.Flush())
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "some/* text */ x {{ // skip {{ me\n thing", lexFun)
+	l.AddIgnoreRange("/*", "*/", false)
+	l.AddIgnoreToEOL("//")
+	l.Run()
+
+	assert.Equal(suite.T(), []string{"somex", "{{", "thing"}, values)
+}
+
+func (suite *GoblexTestSuite) TestRemoveIgnoreRange() {
+	suite.T().Parallel()
+
+	values := suite.lexUntilBracket("a /* b */ c]", func(l *goblex.Lexer) {
+		l.AddIgnoreRange("/*", "*/", false)
+		l.RemoveIgnoreTokens("/*")
+	})
+
+	assert.Equal(suite.T(), []string{"a/*b*/c"}, values)
+}
+
+func (suite *GoblexTestSuite) TestOverlappingIgnoresLongestMatch() {
+	suite.T().Parallel()
+
+	input := "a/b*c // x]\nd /* y] */e]"
+
+	for i := 0; i < 100; i++ {
+		values := suite.lexUntilBracket(input, func(l *goblex.Lexer) {
+			l.AddIgnoreTokens("/", "*")
+			l.AddIgnoreToEOL("//")
+			l.AddIgnoreRange("/*", "*/", false)
+		})
+
+		suite.Require().Equal([]string{"abcde"}, values, "run %d", i)
+	}
+}
+
+func (suite *GoblexTestSuite) TestOverlappingIgnoresRemoveLonger() {
+	suite.T().Parallel()
+
+	for i := 0; i < 100; i++ {
+		values := suite.lexUntilBracket("a//b // c]", func(l *goblex.Lexer) {
+			l.AddIgnoreTokens("/")
+			l.AddIgnoreToEOL("//")
+			l.RemoveIgnoreTokens("//")
+		})
+
+		suite.Require().Equal([]string{"abc"}, values, "run %d", i)
+	}
+}
+
+func (suite *GoblexTestSuite) TestCaptureWhileIn() {
+	suite.T().Parallel()
+	var values []string
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		for lexer.CaptureUntil(true, "0x") {
+			lexer.SkipCurrentToken(true)
+			if lexer.CaptureWhileIn(unicode.ASCII_Hex_Digit) {
+				values = append(values, lexer.Flush())
+			}
+		}
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "mov 0xDEADbeef, 0x1f/*c*/2g 0xzz", lexFun)
+	l.AddIgnoreRange("/*", "*/", false)
+	l.Run()
+
+	assert.Equal(suite.T(), []string{"DEADbeef", "1f2"}, values)
+}
+
+func (suite *GoblexTestSuite) TestCaptureWhile() {
+	suite.T().Parallel()
+	var values []string
+
+	isBase64 := func(ch rune) bool {
+		return (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '+' || ch == '/' || ch == '='
+	}
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		for lexer.CaptureWhile(isBase64) {
+			values = append(values, lexer.Flush())
+			lexer.CaptureUntil(true, ",")
+			lexer.SkipCurrentToken(true)
+		}
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "  aGVsbG8=  , d29y+/bGQ=,", lexFun)
+	l.Run()
+
+	assert.Equal(suite.T(), []string{"aGVsbG8=", "d29y+/bGQ="}, values)
+}
+
+func (suite *GoblexTestSuite) TestCaptureWhileNoWhitespace() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", " abc", lexFun)
+	l.AutoEatWhitespace = false
+
+	assert.False(suite.T(), l.CaptureWhile(unicode.IsLetter))
+	assert.True(suite.T(), l.CurrentTokenIs(" "))
+}
+
+func (suite *GoblexTestSuite) TestCaptureUntilFunc() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "   some text /* 1 */ here 42 rest", lexFun)
+	l.AddIgnoreRange("/*", "*/", false)
+
+	assert.True(suite.T(), l.CaptureUntilFunc(unicode.IsDigit))
+	assert.Equal(suite.T(), "some text  here ", l.Flush())
+	assert.True(suite.T(), l.ConsumeCurrentToken(true))
+	assert.Equal(suite.T(), "4", l.Flush())
+
+	assert.False(suite.T(), l.CaptureUntilFunc(unicode.IsPunct))
+	assert.Equal(suite.T(), "2 rest", l.Flush())
+	assert.True(suite.T(), l.IsEOF())
+}
@@ -0,0 +1,131 @@
+package goblex
+
+import "unicode"
+
+// IdentRule decides which runes make up an identifier captured by CaptureIdent.
+type IdentRule struct {
+	// Start reports whether a rune may start an identifier
+	Start func(rune) bool
+	// Continue reports whether a rune may appear in an identifier after the first rune
+	Continue func(rune) bool
+}
+
+var (
+	// DefaultIdentRule accepts letters, digits and '_' anywhere in an identifier, including at the start.
+	// This is the rule CaptureIdent has always used.
+	DefaultIdentRule = IdentRule{
+		Start:    isDefaultIdent,
+		Continue: isDefaultIdent,
+	}
+
+	// GoIdentRule accepts identifiers as defined by the Go language: a letter or '_' followed by
+	// letters, digits and '_'.
+	GoIdentRule = IdentRule{
+		Start:    isLetterOrUnderscore,
+		Continue: isDefaultIdent,
+	}
+
+	// CIdentRule accepts identifiers as defined by the C language: an ASCII letter or '_' followed by
+	// ASCII letters, digits and '_'.
+	CIdentRule = IdentRule{
+		Start:    isASCIILetterOrUnderscore,
+		Continue: isASCIIIdent,
+	}
+
+	// CSSIdentRule accepts kebab-case identifiers like the ones used in CSS: a letter, '_' or '-'
+	// followed by letters, digits, '_' and '-'.
+	CSSIdentRule = IdentRule{
+		Start: func(ch rune) bool {
+			return ch == '-' || isLetterOrUnderscore(ch)
+		},
+		Continue: func(ch rune) bool {
+			return ch == '-' || isDefaultIdent(ch)
+		},
+	}
+
+	// ShellIdentRule accepts shell variable names, optionally starting with '$': a '$', ASCII letter or
+	// '_' followed by ASCII letters, digits and '_'.
+	ShellIdentRule = IdentRule{
+		Start: func(ch rune) bool {
+			return ch == '$' || isASCIILetterOrUnderscore(ch)
+		},
+		Continue: isASCIIIdent,
+	}
+
+	// XIDIdentRule accepts identifiers as defined by Unicode UAX #31 using the XID_Start and
+	// XID_Continue properties, derived from the ID_Start and ID_Continue properties available in the
+	// unicode package by removing the runes that are not stable under NFKC normalization.
+	XIDIdentRule = IdentRule{
+		Start:    isXIDStart,
+		Continue: isXIDContinue,
+	}
+)
+
+var (
+	xidStartTables    = []*unicode.RangeTable{unicode.L, unicode.Nl, unicode.Other_ID_Start}
+	xidContinueTables = []*unicode.RangeTable{
+		unicode.L, unicode.Nl, unicode.Other_ID_Start,
+		unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue,
+	}
+	xidExcludeTables = []*unicode.RangeTable{unicode.Pattern_Syntax, unicode.Pattern_White_Space}
+
+	// xidStartUnstable are the ID_Start runes that are not XID_Start, see UAX #31 section 5.1
+	xidStartUnstable = &unicode.RangeTable{R16: []unicode.Range16{
+		{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+		{Lo: 0x0e33, Hi: 0x0e33, Stride: 1},
+		{Lo: 0x0eb3, Hi: 0x0eb3, Stride: 1},
+		{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+		{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+		{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+		{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+		{Lo: 0xff9e, Hi: 0xff9f, Stride: 1},
+	}}
+	// xidContinueUnstable are the ID_Continue runes that are not XID_Continue, see UAX #31 section 5.1
+	xidContinueUnstable = &unicode.RangeTable{R16: []unicode.Range16{
+		{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+		{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+		{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+		{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+		{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+	}}
+)
+
+// identRule returns the lexer's IdentRule falling back to DefaultIdentRule for missing predicates.
+func (lxr *Lexer) identRule() IdentRule {
+	rule := lxr.IdentRule
+	if rule.Start == nil {
+		rule.Start = DefaultIdentRule.Start
+	}
+
+	if rule.Continue == nil {
+		rule.Continue = DefaultIdentRule.Continue
+	}
+
+	return rule
+}
+
+func isDefaultIdent(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_'
+}
+
+func isLetterOrUnderscore(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+func isASCIILetterOrUnderscore(ch rune) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '_'
+}
+
+func isASCIIIdent(ch rune) bool {
+	return isASCIILetterOrUnderscore(ch) || (ch >= '0' && ch <= '9')
+}
+
+func isXIDStart(ch rune) bool {
+	return unicode.IsOneOf(xidStartTables, ch) && !unicode.IsOneOf(xidExcludeTables, ch) &&
+		!unicode.Is(xidStartUnstable, ch)
+}
+
+func isXIDContinue(ch rune) bool {
+	return unicode.IsOneOf(xidContinueTables, ch) && !unicode.IsOneOf(xidExcludeTables, ch) &&
+		!unicode.Is(xidContinueUnstable, ch)
+}
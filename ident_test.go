@@ -0,0 +1,77 @@
+package goblex_test
+
+import (
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *GoblexTestSuite) lexIdents(input string, rule goblex.IdentRule) []string {
+	var values []string
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.IsEOF() {
+			return nil
+		}
+
+		if lexer.CaptureIdent() {
+			values = append(values, lexer.Flush())
+		} else {
+			// skip a single non ident rune
+			lexer.CaptureUntilFunc(func(rune) bool { return true })
+			lexer.SkipCurrentToken(true)
+		}
+		return lexFun
+	}
+
+	l := goblex.NewLexer("idents", input, lexFun)
+	l.IdentRule = rule
+	l.Run()
+
+	return values
+}
+
+func (suite *GoblexTestSuite) TestIdentRules() {
+	suite.T().Parallel()
+
+	// '_' is not XID_Start, '₁' is not XID_Continue and '℘' is Other_ID_Start
+	input := "9lives _go $HOME font-size -webkit-box naïve x₁ ℘x"
+
+	cases := []struct {
+		rule     goblex.IdentRule
+		expected []string
+	}{
+		{goblex.DefaultIdentRule, []string{"9lives", "_go", "HOME", "font", "size", "webkit", "box", "naïve", "x", "x"}},
+		{goblex.GoIdentRule, []string{"lives", "_go", "HOME", "font", "size", "webkit", "box", "naïve", "x", "x"}},
+		{goblex.CIdentRule, []string{"lives", "_go", "HOME", "font", "size", "webkit", "box", "na", "ve", "x", "x"}},
+		{goblex.CSSIdentRule, []string{"lives", "_go", "HOME", "font-size", "-webkit-box", "naïve", "x", "x"}},
+		{goblex.ShellIdentRule, []string{"lives", "_go", "$HOME", "font", "size", "webkit", "box", "na", "ve", "x", "x"}},
+		{goblex.XIDIdentRule, []string{"lives", "go", "HOME", "font", "size", "webkit", "box", "naïve", "x", "℘x"}},
+		{goblex.IdentRule{}, []string{"9lives", "_go", "HOME", "font", "size", "webkit", "box", "naïve", "x", "x"}},
+	}
+
+	for i, c := range cases {
+		assert.Equal(suite.T(), c.expected, suite.lexIdents(input, c.rule), "case %d", i)
+	}
+}
+
+func (suite *GoblexTestSuite) TestXIDIdentRuleNFKC() {
+	suite.T().Parallel()
+
+	// U+037A, U+0E33 and U+309B are ID_Start but not XID_Start, U+0E33 is still XID_Continue
+	input := "\u037aab x\u0e33 \u309bc d\u309be"
+
+	assert.Equal(suite.T(), []string{"ab", "x\u0e33", "c", "d", "e"}, suite.lexIdents(input, goblex.XIDIdentRule))
+}
+
+func (suite *GoblexTestSuite) TestIdentRuleCustom() {
+	suite.T().Parallel()
+
+	rule := goblex.IdentRule{
+		Start:    func(ch rune) bool { return ch == '@' },
+		Continue: func(ch rune) bool { return ch >= 'a' && ch <= 'z' },
+	}
+
+	assert.Equal(suite.T(), []string{"@foo", "@bar"}, suite.lexIdents("foo @foo @barBaz", rule))
+}
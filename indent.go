@@ -0,0 +1,141 @@
+package goblex
+
+// Indentation configures indentation tracking for indentation-sensitive languages like Python or YAML.
+//
+// When set on a Lexer, the leading whitespace of every line that holds an emitted token is measured and
+// compared to an indent stack. Before the first token of such a line is emitted, the lexer emits a
+// NewlineType token ending the previous line, an IndentType token if the line is indented further than
+// the previous one or one DedentType token for every level it is indented less. At the end of the input
+// a final NewlineType token and a DedentType token for every open level are emitted before the EOF token.
+//
+// Lines that emit no tokens, like blank lines or lines holding only ignored comments, never change the
+// indentation. The synthetic tokens have an empty value and are positioned at the start of the token
+// that caused them, except for NewlineType tokens which are positioned at the end of the line's last
+// token.
+//
+// Indentation only works when whitespace is skipped rather than captured at the start of a line, e.g. by
+// setting AutoEatWhitespace.
+type Indentation struct {
+	// TabWidth is the number of columns between tab stops used to measure tabs. defaults to 8
+	TabWidth int
+	// AllowMixed is a flag that when set to true allows a line to be indented with both tabs and spaces.
+	// When false such lines are reported as ErrIndentation errors. defaults to false
+	AllowMixed bool
+	// IndentType is the TokenType of emitted INDENT tokens
+	IndentType TokenType
+	// DedentType is the TokenType of emitted DEDENT tokens
+	DedentType TokenType
+	// NewlineType is the TokenType of emitted NEWLINE tokens
+	NewlineType TokenType
+}
+
+// lineIndent measures the leading whitespace of the line being read.
+type lineIndent struct {
+	width  int
+	spaces bool
+	tabs   bool
+	done   bool
+}
+
+// advance updates the measurement after ch has been read.
+func (li *lineIndent) advance(ch rune, tabWidth int) {
+	switch {
+	case ch == '\n':
+		*li = lineIndent{}
+	case li.done:
+	case ch == ' ':
+		li.width++
+		li.spaces = true
+	case ch == '\t':
+		li.width = (li.width/tabWidth + 1) * tabWidth
+		li.tabs = true
+	default:
+		li.done = true
+	}
+}
+
+func (ind *Indentation) tabWidth() int {
+	if ind.TabWidth < 1 {
+		return 8
+	}
+
+	return ind.TabWidth
+}
+
+// emitLayout emits the NEWLINE, INDENT and DEDENT tokens due before a token captured from span and
+// records the end of span as the end of the current line's last token.
+func (lxr *Lexer) emitLayout(span capturedSpan) {
+	ind := lxr.Indentation
+	if ind == nil {
+		return
+	}
+
+	if span.start.Line > lxr.layoutLine {
+		lxr.indentLine(ind, span.start, span.indent)
+	}
+	lxr.layoutEnd = span.end
+}
+
+// indentLine emits the layout tokens for the first token of a new line starting at start and indented
+// by li.
+func (lxr *Lexer) indentLine(ind *Indentation, start Position, li lineIndent) {
+	if lxr.layoutLine > 0 {
+		lxr.pushLayout(ind.NewlineType, lxr.layoutEnd)
+	}
+	lxr.layoutLine = start.Line
+
+	if li.spaces && li.tabs && !ind.AllowMixed {
+		lxr.errorf(CodeIndentation, start, "mixed tabs and spaces in indentation")
+	}
+
+	top := 0
+	if len(lxr.indents) > 0 {
+		top = lxr.indents[len(lxr.indents)-1]
+	}
+
+	if li.width > top {
+		lxr.indents = append(lxr.indents, li.width)
+		lxr.pushLayout(ind.IndentType, start)
+		return
+	}
+
+	for len(lxr.indents) > 0 && li.width < lxr.indents[len(lxr.indents)-1] {
+		lxr.indents = lxr.indents[:len(lxr.indents)-1]
+		lxr.pushLayout(ind.DedentType, start)
+	}
+
+	top = 0
+	if len(lxr.indents) > 0 {
+		top = lxr.indents[len(lxr.indents)-1]
+	}
+
+	if li.width != top {
+		lxr.errorf(CodeIndentation, start, "unindent does not match any outer indentation level")
+	}
+}
+
+// flushLayout emits the final NEWLINE and the DEDENT tokens of all open indentation levels once the end
+// of the input has been reached.
+func (lxr *Lexer) flushLayout() {
+	ind := lxr.Indentation
+	if lxr.layoutLine < 1 {
+		return
+	}
+
+	lxr.layoutLine = 0
+	if ind == nil {
+		// indentation was switched off while lexing, so there are no levels left to close
+		lxr.indents = nil
+		return
+	}
+
+	lxr.pushLayout(ind.NewlineType, lxr.layoutEnd)
+	for range lxr.indents {
+		lxr.pushLayout(ind.DedentType, lxr.currentPos)
+	}
+	lxr.indents = nil
+}
+
+func (lxr *Lexer) pushLayout(tokenType TokenType, pos Position) {
+	lxr.pushDefault(defaultToken{tokenType: tokenType, start: pos, end: pos})
+}
@@ -0,0 +1,217 @@
+package goblex_test
+
+import (
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	indentTokenType goblex.TokenType = iota + 20
+	dedentTokenType
+	newlineTokenType
+)
+
+func (suite *GoblexTestSuite) lexIndented(input string, indentation *goblex.Indentation) []string {
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.IsEOF() {
+			return nil
+		}
+
+		if lexer.CaptureIdent() {
+			lexer.Emit(identTokenType)
+		} else {
+			// skip a single non ident rune like ':'
+			lexer.CaptureUntilFunc(func(rune) bool { return true })
+			lexer.SkipCurrentToken(true)
+		}
+		return lexFun
+	}
+
+	l := goblex.NewLexer("indented", input, lexFun)
+	l.AutoEatWhitespace = true
+	l.AddIgnoreToEOL("#")
+	l.Indentation = indentation
+
+	var values []string
+	for {
+		token := l.NextEmittedToken()
+		switch token.Type() {
+		case goblex.TokenTypeEOF:
+			return values
+		case goblex.TokenTypeError:
+			values = append(values, "ERROR")
+		case indentTokenType:
+			values = append(values, "INDENT")
+		case dedentTokenType:
+			values = append(values, "DEDENT")
+		case newlineTokenType:
+			values = append(values, "NEWLINE")
+		default:
+			values = append(values, token.String())
+		}
+	}
+}
+
+func (suite *GoblexTestSuite) indentation(tabWidth int, allowMixed bool) *goblex.Indentation {
+	return &goblex.Indentation{
+		TabWidth:    tabWidth,
+		AllowMixed:  allowMixed,
+		IndentType:  indentTokenType,
+		DedentType:  dedentTokenType,
+		NewlineType: newlineTokenType,
+	}
+}
+
+func (suite *GoblexTestSuite) TestIndentation() {
+	suite.T().Parallel()
+
+	input := `if a:
+    b
+    if c:
+        d
+
+            # comments and blank lines do not count
+    e
+f`
+
+	values := suite.lexIndented(input, suite.indentation(0, false))
+
+	assert.Equal(suite.T(), []string{
+		"if", "a", "NEWLINE",
+		"INDENT", "b", "NEWLINE",
+		"if", "c", "NEWLINE",
+		"INDENT", "d", "NEWLINE",
+		"DEDENT", "e", "NEWLINE",
+		"DEDENT", "f", "NEWLINE",
+	}, values)
+}
+
+func (suite *GoblexTestSuite) TestIndentationDedentAtEOF() {
+	suite.T().Parallel()
+
+	values := suite.lexIndented("a\n  b\n    c\n", suite.indentation(0, false))
+
+	assert.Equal(suite.T(), []string{
+		"a", "NEWLINE", "INDENT", "b", "NEWLINE", "INDENT", "c", "NEWLINE", "DEDENT", "DEDENT",
+	}, values)
+}
+
+func (suite *GoblexTestSuite) TestIndentationTabs() {
+	suite.T().Parallel()
+
+	input := "a\n\tb\n    c\n \td"
+
+	values := suite.lexIndented(input, suite.indentation(4, false))
+	mixed := suite.lexIndented(input, suite.indentation(4, true))
+	wide := suite.lexIndented(input, suite.indentation(0, true))
+
+	assert.Equal(suite.T(), []string{
+		"a", "NEWLINE", "INDENT", "b", "NEWLINE", "c", "NEWLINE", "ERROR", "d", "NEWLINE", "DEDENT",
+	}, values)
+	assert.Equal(suite.T(), []string{
+		"a", "NEWLINE", "INDENT", "b", "NEWLINE", "c", "NEWLINE", "d", "NEWLINE", "DEDENT",
+	}, mixed)
+	assert.Equal(suite.T(), []string{
+		"a", "NEWLINE", "INDENT", "b", "NEWLINE", "DEDENT", "ERROR", "c", "NEWLINE", "INDENT", "d", "NEWLINE", "DEDENT",
+	}, wide)
+}
+
+func (suite *GoblexTestSuite) TestIndentationBadDedent() {
+	suite.T().Parallel()
+
+	values := suite.lexIndented("a\n    b\n  c", suite.indentation(0, false))
+
+	assert.Equal(suite.T(), []string{
+		"a", "NEWLINE", "INDENT", "b", "NEWLINE", "DEDENT", "ERROR", "c", "NEWLINE",
+	}, values)
+}
+
+func (suite *GoblexTestSuite) TestIndentationPositions() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		for lexer.CaptureIdent() {
+			lexer.Emit(identTokenType)
+		}
+		return nil
+	}
+
+	l := goblex.NewLexer("indented", "ab\n  cd", lexFun)
+	l.AutoEatWhitespace = true
+	l.Indentation = suite.indentation(0, false)
+
+	var tokens []goblex.PositionedToken
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+		tokens = append(tokens, token.(goblex.PositionedToken))
+	}
+
+	assert.Len(suite.T(), tokens, 6)
+	assert.Equal(suite.T(), "1:3", tokens[1].Start().String())
+	assert.Equal(suite.T(), "", tokens[1].String())
+	assert.Equal(suite.T(), "2:3", tokens[2].Start().String())
+	assert.Equal(suite.T(), "2:5", tokens[4].Start().String())
+	assert.Equal(suite.T(), dedentTokenType, tokens[5].Type())
+}
+
+func (suite *GoblexTestSuite) TestIndentationSwitchedOff() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		for lexer.CaptureIdent() {
+			lexer.Emit(identTokenType)
+			lexer.Indentation = nil
+		}
+		return nil
+	}
+
+	l := goblex.NewLexer("indented", "ab\n  cd", lexFun)
+	l.AutoEatWhitespace = true
+	l.Indentation = suite.indentation(0, false)
+
+	var values []string
+	for token := range l.All() {
+		values = append(values, token.String())
+	}
+
+	assert.Equal(suite.T(), []string{"ab", "cd", "EOF"}, values)
+}
+
+func (suite *GoblexTestSuite) TestIndentationCustomTokens() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		for lexer.CaptureIdent() {
+			lexer.EmitToken(positionedSliceToken{value: lexer.Flush()})
+		}
+		return nil
+	}
+
+	l := goblex.NewLexer("indented", "ab\n  cd\nef", lexFun)
+	l.AutoEatWhitespace = true
+	l.Indentation = suite.indentation(0, false)
+
+	var values []string
+	for token := range l.All() {
+		switch token.Type() {
+		case indentTokenType:
+			values = append(values, "INDENT")
+		case dedentTokenType:
+			values = append(values, "DEDENT")
+		case newlineTokenType:
+			values = append(values, "NEWLINE")
+		default:
+			values = append(values, token.String())
+		}
+	}
+
+	assert.Equal(suite.T(), []string{
+		"ab", "NEWLINE", "INDENT", "cd", "NEWLINE", "DEDENT", "ef", "NEWLINE", "EOF",
+	}, values)
+}
@@ -0,0 +1,59 @@
+package goblex
+
+import "iter"
+
+// All returns an iterator over the tokens emitted by the lexer that ends with the EOF token. This is the
+// preferred way for parsers to consume a lexer, e.g.
+//
+//	for token := range lexer.All() {
+//		...
+//	}
+//
+// Unlike a loop testing IsEOF before calling NextEmittedToken, no tokens emitted after the end of the input
+// was read are missed. Breaking out of the loop early leaves the remaining tokens to be returned by
+// NextEmittedToken or a new iterator.
+func (lxr *Lexer) All() iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		for {
+			token := lxr.NextEmittedToken()
+			if !yield(token) || token.Type() == TokenTypeEOF {
+				return
+			}
+		}
+	}
+}
+
+// AllWithErrors does the same thing as All but also yields the error reported by every token with
+// TokenTypeError, and nil for all other tokens, e.g.
+//
+//	for token, err := range lexer.AllWithErrors() {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Error tokens emitted with EmitToken that do not implement ErrorToken yield a LexError with their value
+// as the message.
+func (lxr *Lexer) AllWithErrors() iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		for token := range lxr.All() {
+			if !yield(token, tokenErr(token)) {
+				return
+			}
+		}
+	}
+}
+
+// tokenErr returns the error reported by token or nil if it is not an error token.
+func tokenErr(token Token) error {
+	if token.Type() != TokenTypeError {
+		return nil
+	}
+
+	if errToken, ok := token.(ErrorToken); ok && errToken.Err() != nil {
+		return errToken.Err()
+	}
+
+	return &LexError{Msg: token.String()}
+}
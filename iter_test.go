@@ -0,0 +1,162 @@
+package goblex_test
+
+import (
+	"errors"
+
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *GoblexTestSuite) lexWords(input string) *goblex.Lexer {
+	var lexFun goblex.LexFn
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.IsEOF() {
+			return nil
+		}
+
+		if !lexer.CaptureIdent() {
+			return lexer.Errorf("expected a word")
+		}
+
+		lexer.Emit(basicTokenType)
+		return lexFun
+	}
+
+	return goblex.NewLexer("words", input, lexFun)
+}
+
+func (suite *GoblexTestSuite) TestAll() {
+	suite.T().Parallel()
+
+	var values []string
+	var types []goblex.TokenType
+	for token := range suite.lexWords("one two three").All() {
+		values = append(values, token.String())
+		types = append(types, token.Type())
+	}
+
+	assert.Equal(suite.T(), []string{"one", "two", "three", goblex.StringEOF}, values)
+	assert.Equal(suite.T(), goblex.TokenTypeEOF, types[3])
+}
+
+func (suite *GoblexTestSuite) TestAllTokensEmittedAtEOF() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureUntil(true, "never")
+		lexer.Emit(basicTokenType)
+		lexer.EmitToken(positionedSliceToken{value: "after"})
+		return nil
+	}
+
+	l := goblex.NewLexer("atEOF", "all of it", lexFun)
+
+	var values []string
+	for token := range l.All() {
+		values = append(values, token.String())
+	}
+
+	assert.Equal(suite.T(), []string{"allofit", "after", goblex.StringEOF}, values)
+}
+
+func (suite *GoblexTestSuite) TestAllBreak() {
+	suite.T().Parallel()
+
+	l := suite.lexWords("one two three")
+
+	for token := range l.All() {
+		assert.Equal(suite.T(), "one", token.String())
+		break
+	}
+
+	assert.Equal(suite.T(), "two", l.NextEmittedToken().String())
+
+	var values []string
+	for token := range l.All() {
+		values = append(values, token.String())
+	}
+
+	assert.Equal(suite.T(), []string{"three", goblex.StringEOF}, values)
+}
+
+func (suite *GoblexTestSuite) TestAllWithErrors() {
+	suite.T().Parallel()
+
+	l := suite.lexWords("one !")
+	l.Recovery = &goblex.Recovery{}
+
+	var values []string
+	var errs []error
+	for token, err := range l.AllWithErrors() {
+		values = append(values, token.String())
+		errs = append(errs, err)
+	}
+
+	assert.Equal(suite.T(), []string{"one", "expected a word", goblex.StringEOF}, values)
+	assert.NoError(suite.T(), errs[0])
+	assert.EqualError(suite.T(), errs[1], "1:5: expected a word")
+	assert.NoError(suite.T(), errs[2])
+}
+
+func (suite *GoblexTestSuite) TestAllWithErrorsCustomErrorToken() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.EmitToken(sliceErrorToken{})
+		return nil
+	}
+
+	l := goblex.NewLexer("custom", "", lexFun)
+
+	for _, err := range l.AllWithErrors() {
+		var lexErr *goblex.LexError
+		assert.True(suite.T(), errors.As(err, &lexErr))
+		assert.Equal(suite.T(), "custom failure", lexErr.Msg)
+		break
+	}
+}
+
+type sliceErrorToken struct{}
+
+func (t sliceErrorToken) Type() goblex.TokenType {
+	return goblex.TokenTypeError
+}
+
+func (t sliceErrorToken) String() string {
+	return "custom failure"
+}
+
+func (suite *GoblexTestSuite) TestTypedLexerAll() {
+	suite.T().Parallel()
+
+	l := goblex.NewTypedLexer(suite.lexWords("1 !"), newNumberToken)
+	l.Recovery = &goblex.Recovery{}
+
+	var texts []string
+	for token := range l.All() {
+		texts = append(texts, token.text)
+	}
+
+	assert.Equal(suite.T(), []string{"1", "expected a word", goblex.StringEOF}, texts)
+}
+
+func (suite *GoblexTestSuite) TestTypedLexerAllWithErrors() {
+	suite.T().Parallel()
+
+	l := goblex.NewTypedLexer(suite.lexWords("a ! b"), newNumberToken)
+
+	var texts []string
+	var errs []error
+	for token, err := range l.AllWithErrors() {
+		texts = append(texts, token.text)
+		errs = append(errs, err)
+		if err != nil {
+			break
+		}
+	}
+
+	assert.Equal(suite.T(), []string{"a", "expected a word"}, texts)
+	assert.NoError(suite.T(), errs[0])
+	assert.EqualError(suite.T(), errs[1], "1:3: expected a word")
+}
@@ -0,0 +1,63 @@
+package goblex
+
+// KeywordTable maps keywords to the TokenType that should be emitted for them.
+//
+// Lookups are a single hash map access so tables with hundreds of keywords are as fast as small ones.
+//
+// The zero value is an empty, case sensitive table ready to use.
+type KeywordTable struct {
+	caseInsensitive bool
+	words           map[string]TokenType
+}
+
+// NewKeywordTable creates an empty KeywordTable. If caseInsensitive is true, keywords are matched using
+// Unicode simple case folding so "SELECT", "select" and "Select" are the same keyword.
+func NewKeywordTable(caseInsensitive bool) *KeywordTable {
+	return &KeywordTable{caseInsensitive: caseInsensitive}
+}
+
+// Add adds word to the table as a keyword of type tokenType. Adding a word that is already in the table
+// replaces it's TokenType.
+func (k *KeywordTable) Add(word string, tokenType TokenType) {
+	if k.words == nil {
+		k.words = make(map[string]TokenType)
+	}
+
+	k.words[k.key(word)] = tokenType
+}
+
+// Lookup returns the TokenType of word and whether word is a keyword in the table.
+func (k *KeywordTable) Lookup(word string) (TokenType, bool) {
+	tokenType, ok := k.words[k.key(word)]
+	return tokenType, ok
+}
+
+// Len returns the number of keywords in the table.
+func (k *KeywordTable) Len() int {
+	return len(k.words)
+}
+
+func (k *KeywordTable) key(word string) string {
+	if k.caseInsensitive {
+		return foldString(word)
+	}
+
+	return word
+}
+
+// EmitIdentOrKeyword emits the current capture buffer like Emit does, using the TokenType the lexer's
+// Keywords table holds for the buffer's value or identType if the value is not a keyword. The TokenType
+// that was emitted is returned.
+//
+// This is typically called right after CaptureIdent.
+func (lxr *Lexer) EmitIdentOrKeyword(identType TokenType) TokenType {
+	tokenType := identType
+	if lxr.Keywords != nil {
+		if kw, ok := lxr.Keywords.Lookup(lxr.tokenBuffer.String()); ok {
+			tokenType = kw
+		}
+	}
+
+	lxr.Emit(tokenType)
+	return tokenType
+}
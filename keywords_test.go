@@ -0,0 +1,128 @@
+package goblex_test
+
+import (
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	identTokenType goblex.TokenType = iota + 10
+	selectTokenType
+	fromTokenType
+	straßeTokenType
+)
+
+func (suite *GoblexTestSuite) lexKeywords(input string, keywords *goblex.KeywordTable) []goblex.Token {
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.IsEOF() {
+			return nil
+		}
+
+		if lexer.CaptureIdent() {
+			lexer.EmitIdentOrKeyword(identTokenType)
+		} else {
+			lexer.CaptureUntilFunc(func(rune) bool { return true })
+			lexer.SkipCurrentToken(true)
+		}
+		return lexFun
+	}
+
+	l := goblex.NewLexer("keywords", input, lexFun)
+	l.AutoEatWhitespace = true
+	l.Keywords = keywords
+
+	var tokens []goblex.Token
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens
+}
+
+func (suite *GoblexTestSuite) TestKeywordTable() {
+	suite.T().Parallel()
+
+	kw := goblex.NewKeywordTable(false)
+	kw.Add("select", selectTokenType)
+	kw.Add("from", fromTokenType)
+
+	tokens := suite.lexKeywords("select name FROM people", kw)
+
+	assert.Len(suite.T(), tokens, 4)
+	assert.Equal(suite.T(), selectTokenType, tokens[0].Type())
+	assert.Equal(suite.T(), identTokenType, tokens[1].Type())
+	assert.Equal(suite.T(), "name", tokens[1].String())
+	assert.Equal(suite.T(), identTokenType, tokens[2].Type())
+	assert.Equal(suite.T(), identTokenType, tokens[3].Type())
+}
+
+func (suite *GoblexTestSuite) TestKeywordTableCaseInsensitive() {
+	suite.T().Parallel()
+
+	kw := goblex.NewKeywordTable(true)
+	kw.Add("SELECT", selectTokenType)
+	kw.Add("from", fromTokenType)
+	kw.Add("straße", straßeTokenType)
+
+	tokens := suite.lexKeywords("Select name FROM STRASSE STRAẞE", kw)
+
+	assert.Len(suite.T(), tokens, 5)
+	assert.Equal(suite.T(), selectTokenType, tokens[0].Type())
+	assert.Equal(suite.T(), "Select", tokens[0].String())
+	assert.Equal(suite.T(), identTokenType, tokens[1].Type())
+	assert.Equal(suite.T(), fromTokenType, tokens[2].Type())
+	// simple folding does not expand ß to ss but does fold ẞ to ß
+	assert.Equal(suite.T(), identTokenType, tokens[3].Type())
+	assert.Equal(suite.T(), straßeTokenType, tokens[4].Type())
+}
+
+func (suite *GoblexTestSuite) TestKeywordTableLookup() {
+	suite.T().Parallel()
+
+	kw := goblex.NewKeywordTable(true)
+	kw.Add("Kelvin", selectTokenType)
+	kw.Add("kelvin", fromTokenType)
+
+	// the K is KELVIN SIGN which folds with k and K
+	tokenType, ok := kw.Lookup("\u212Aelvin")
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), fromTokenType, tokenType)
+	assert.Equal(suite.T(), 1, kw.Len())
+
+	_, ok = kw.Lookup("celsius")
+	assert.False(suite.T(), ok)
+}
+
+func (suite *GoblexTestSuite) TestKeywordTableZeroValue() {
+	suite.T().Parallel()
+
+	var kw goblex.KeywordTable
+	_, ok := kw.Lookup("select")
+	assert.False(suite.T(), ok)
+	assert.Equal(suite.T(), 0, kw.Len())
+
+	kw.Add("select", selectTokenType)
+	tokenType, ok := kw.Lookup("select")
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), selectTokenType, tokenType)
+
+	_, ok = kw.Lookup("SELECT")
+	assert.False(suite.T(), ok)
+}
+
+func (suite *GoblexTestSuite) TestEmitIdentOrKeywordNoTable() {
+	suite.T().Parallel()
+
+	tokens := suite.lexKeywords("select from", nil)
+
+	assert.Len(suite.T(), tokens, 2)
+	assert.Equal(suite.T(), identTokenType, tokens[0].Type())
+	assert.Equal(suite.T(), identTokenType, tokens[1].Type())
+}
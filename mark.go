@@ -0,0 +1,167 @@
+package goblex
+
+// Mark is a checkpoint of the lexer's state created by Mark that can later be returned to with Rewind
+// or released with Commit.
+type Mark struct {
+	id             int
+	depth          int
+	history        int
+	pushed         int
+	currentRune    rune
+	currentSize    int
+	currentRaw     byte
+	currentPos     Position
+	eof            bool
+	captured       string
+	captureStarted bool
+	captureStart   Position
+	captureEnd     Position
+	flushed        *capturedSpan
+	lastKnownToken string
+	lastKnownFold  bool
+	inputErrSent   bool
+	inputErrDue    bool
+	inputErrPos    Position
+	lineIndent     lineIndent
+	captureIndent  lineIndent
+	indents        []int
+	layoutLine     int
+	layoutEnd      Position
+	triviaStart    int
+	segments       []Segment
+	firstErr       *LexError
+	lastErr        *LexError
+	errCount       int
+	stopped        bool
+	modes          modeState
+}
+
+// Mark creates a checkpoint of the current read position, capture buffer and last known token so that
+// a LexFn can speculatively lex some input and back out with Rewind if it turns out to be the wrong
+// alternative.
+//
+// Modes pushed or popped after the Mark was created are undone by Rewind.
+//
+// Every Mark must be released by passing it to either Rewind or Commit. Marks can be nested and
+// releasing a Mark also releases any Marks created after it.
+func (lxr *Lexer) Mark() Mark {
+	lxr.markDepth++
+	lxr.lastMarkID++
+	lxr.markIDs = append(lxr.markIDs, lxr.lastMarkID)
+	lxr.logDebug("mark %d at %s", lxr.markDepth, lxr.currentPos)
+
+	return Mark{
+		id:             lxr.lastMarkID,
+		depth:          lxr.markDepth,
+		history:        len(lxr.history),
+		pushed:         lxr.pushedTokens(),
+		currentRune:    lxr.currentRune,
+		currentSize:    lxr.currentSize,
+		currentRaw:     lxr.currentRaw,
+		currentPos:     lxr.currentPos,
+		eof:            lxr.eof,
+		captured:       lxr.tokenBuffer.String(),
+		captureStarted: lxr.captureStarted,
+		captureStart:   lxr.captureStart,
+		captureEnd:     lxr.captureEnd,
+		flushed:        lxr.flushed,
+		lastKnownToken: lxr.lastKnownToken,
+		lastKnownFold:  lxr.lastKnownFold,
+		inputErrSent:   lxr.inputErrSent,
+		inputErrDue:    lxr.inputErrDue,
+		inputErrPos:    lxr.inputErrPos,
+		lineIndent:     lxr.lineIndent,
+		captureIndent:  lxr.captureIndent,
+		indents:        append([]int(nil), lxr.indents...),
+		layoutLine:     lxr.layoutLine,
+		layoutEnd:      lxr.layoutEnd,
+		triviaStart:    lxr.triviaStart,
+		segments:       append([]Segment(nil), lxr.segments...),
+		firstErr:       lxr.firstErr,
+		lastErr:        lxr.lastErr,
+		errCount:       lxr.errCount,
+		stopped:        lxr.stopped,
+		modes:          lxr.saveModes(),
+	}
+}
+
+// Rewind restores the lexer to the state it was in when m was created and releases m.
+//
+// All input read since m was created will be read again and any tokens emitted since m was created
+// that have not yet been retrieved by NextEmittedToken are discarded.
+//
+// Rewinding a Mark that has already been released does nothing.
+func (lxr *Lexer) Rewind(m Mark) {
+	if !lxr.isLive(m) {
+		return
+	}
+
+	lxr.logDebug("rewind %d to %s", m.depth, m.currentPos)
+	if len(lxr.history) > m.history {
+		restore := make([]cachedRune, 0, len(lxr.history)-m.history+len(lxr.runeCache))
+		restore = append(restore, lxr.history[m.history+1:]...)
+		if !lxr.eof {
+			restore = append(restore, lxr.current())
+		}
+		lxr.runeCache = append(restore, lxr.runeCache...)
+	}
+
+	lxr.history = lxr.history[:m.history]
+	lxr.truncateTokens(m.pushed)
+
+	lxr.currentRune = m.currentRune
+	lxr.currentSize = m.currentSize
+	lxr.currentRaw = m.currentRaw
+	lxr.currentPos = m.currentPos
+	lxr.eof = m.eof
+	lxr.tokenBuffer.Reset()
+	lxr.tokenBuffer.WriteString(m.captured)
+	lxr.captureStarted = m.captureStarted
+	lxr.captureStart = m.captureStart
+	lxr.captureEnd = m.captureEnd
+	lxr.flushed = m.flushed
+	lxr.lastKnownToken = m.lastKnownToken
+	lxr.lastKnownFold = m.lastKnownFold
+	lxr.inputErrSent = m.inputErrSent
+	lxr.inputErrDue = m.inputErrDue
+	lxr.inputErrPos = m.inputErrPos
+	lxr.lineIndent = m.lineIndent
+	lxr.captureIndent = m.captureIndent
+	lxr.indents = m.indents
+	lxr.layoutLine = m.layoutLine
+	lxr.layoutEnd = m.layoutEnd
+	lxr.triviaStart = m.triviaStart
+	lxr.segments = append([]Segment(nil), m.segments...)
+	lxr.firstErr = m.firstErr
+	lxr.lastErr = m.lastErr
+	lxr.errCount = m.errCount
+	lxr.stopped = m.stopped
+	lxr.restoreModes(m.modes)
+
+	lxr.release(m)
+}
+
+// Commit releases m keeping everything that was lexed since it was created.
+//
+// Committing a Mark that has already been released does nothing.
+func (lxr *Lexer) Commit(m Mark) {
+	if !lxr.isLive(m) {
+		return
+	}
+
+	lxr.logDebug("commit %d at %s", m.depth, lxr.currentPos)
+	lxr.release(m)
+}
+
+// isLive returns whether m has not been released yet, either directly or by releasing an outer Mark.
+func (lxr *Lexer) isLive(m Mark) bool {
+	return m.depth >= 1 && m.depth <= lxr.markDepth && lxr.markIDs[m.depth-1] == m.id
+}
+
+func (lxr *Lexer) release(m Mark) {
+	lxr.markDepth = m.depth - 1
+	lxr.markIDs = lxr.markIDs[:lxr.markDepth]
+	if lxr.markDepth == 0 {
+		lxr.history = nil
+	}
+}
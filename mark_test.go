@@ -0,0 +1,171 @@
+package goblex_test
+
+import (
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *GoblexTestSuite) TestRewindAfterFailedCapture() {
+	suite.T().Parallel()
+	var values []string
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		m := lexer.Mark()
+		if lexer.CaptureUntil(true, "]") {
+			lexer.Commit(m)
+			lexer.Emit(basicTokenType)
+			lexer.SkipCurrentToken(true)
+			return lexFun
+		}
+
+		// no closing bracket, back out and lex a plain ident instead
+		lexer.Rewind(m)
+		if lexer.CaptureIdent() {
+			lexer.Emit(basicTokenType)
+			return lexFun
+		}
+
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "a b] c d", lexFun)
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		values = append(values, token.String())
+	}
+
+	assert.Equal(suite.T(), []string{"ab", "c", "d"}, values)
+}
+
+func (suite *GoblexTestSuite) TestRewindRestoresState() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "héllo {{wörld}} !", lexFun)
+	l.CaptureUntil(false, "{{")
+	before := l.Position()
+
+	m := l.Mark()
+	l.ConsumeCurrentToken(false)
+	l.CaptureIdent()
+	l.Emit(basicTokenType)
+	l.CaptureUntil(true, "nope")
+	assert.True(suite.T(), l.IsEOF())
+
+	l.Rewind(m)
+	assert.Equal(suite.T(), before, l.Position())
+	assert.True(suite.T(), l.CurrentTokenIs("{{"))
+
+	// the capture buffer and last known token are restored
+	assert.True(suite.T(), l.ConsumeCurrentToken(false))
+	assert.True(suite.T(), l.CaptureIdent())
+	l.Emit(basicTokenType)
+
+	token := l.NextEmittedToken().(goblex.PositionedToken)
+	assert.Equal(suite.T(), "héllo {{wörld", token.String())
+	assert.Equal(suite.T(), goblex.Position{Offset: 0, Line: 1, Column: 1}, token.Start())
+	assert.Equal(suite.T(), goblex.Position{Offset: 15, Line: 1, Column: 14}, token.End())
+
+	assert.Equal(suite.T(), goblex.TokenTypeEOF, l.NextEmittedToken().Type())
+}
+
+func (suite *GoblexTestSuite) TestNestedMarks() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "one two three four", lexFun)
+
+	outer := l.Mark()
+	l.CaptureIdent()
+	l.Flush()
+
+	inner := l.Mark()
+	l.CaptureIdent()
+	l.Flush()
+	l.Commit(inner)
+
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "three", l.Flush())
+
+	l.Rewind(outer)
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "one", l.Flush())
+
+	// released marks are ignored
+	l.CaptureIdent()
+	l.Flush()
+	l.Rewind(outer)
+	l.Rewind(inner)
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "three", l.Flush())
+}
+
+func (suite *GoblexTestSuite) TestRewindInnerKeepsOuter() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "one two three", lexFun)
+
+	outer := l.Mark()
+	l.CaptureIdent()
+	l.Flush()
+
+	inner := l.Mark()
+	l.CaptureIdent()
+	l.Flush()
+	l.Rewind(inner)
+
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "two", l.Flush())
+
+	l.Rewind(outer)
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "one", l.Flush())
+}
+
+func (suite *GoblexTestSuite) TestRewindStaleMarkAtSameDepth() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "one two three four", lexFun)
+
+	stale := l.Mark()
+	l.CaptureIdent()
+	l.Flush()
+	l.Commit(stale)
+
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "two", l.Flush())
+
+	live := l.Mark()
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "three", l.Flush())
+
+	// stale has the same depth as live but was already released
+	l.Rewind(stale)
+	l.Commit(stale)
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "four", l.Flush())
+
+	l.Rewind(live)
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "three", l.Flush())
+}
@@ -0,0 +1,173 @@
+package goblex
+
+// Mode bundles the settings used to lex an embedded sub-language, e.g. the actions between "{{" and "}}"
+// in a template or the script inside an HTML <script> element, so they can be switched together with
+// PushMode and PopMode.
+//
+// A Mode can be pushed any number of times. Changes made to the lexer's ignore tokens while a Mode is
+// active do not change the Mode itself.
+type Mode struct {
+	// Name is a string used to identify this mode for debugging purposes
+	Name string
+	// Begin is the LexFn returned by PushMode to start lexing in this mode and by PopMode when returning
+	// to this mode from a nested one
+	Begin LexFn
+	// AutoEatWhitespace replaces the lexer's AutoEatWhitespace while this mode is active
+	AutoEatWhitespace bool
+	// IdentRule replaces the lexer's IdentRule while this mode is active. defaults to DefaultIdentRule
+	IdentRule IdentRule
+	ignores   ignoreSet
+}
+
+// savedMode holds the settings of a mode that was active when another one was pushed.
+type savedMode struct {
+	mode              *Mode
+	autoEatWhitespace bool
+	identRule         IdentRule
+	ignores           ignoreSet
+}
+
+// modeState is the active mode and the stack of saved modes recorded by a Mark.
+type modeState struct {
+	active savedMode
+	stack  []savedMode
+}
+
+// NewMode creates a new Mode with the given name using the begin LexFn as the entry point when lexing in
+// this mode. AutoEatWhitespace is enabled and no tokens are ignored.
+func NewMode(name string, begin LexFn) *Mode {
+	return &Mode{
+		Name:              name,
+		Begin:             begin,
+		AutoEatWhitespace: true,
+		IdentRule:         DefaultIdentRule,
+	}
+}
+
+// AddIgnoreTokens does the same thing as Lexer.AddIgnoreTokens for this mode.
+func (m *Mode) AddIgnoreTokens(tokens ...string) {
+	m.ignores.addTokens(tokens)
+}
+
+// AddIgnoreRange does the same thing as Lexer.AddIgnoreRange for this mode.
+func (m *Mode) AddIgnoreRange(open, close string, nested bool) {
+	m.ignores.addRange(open, close, nested)
+}
+
+// AddIgnoreToEOL does the same thing as Lexer.AddIgnoreToEOL for this mode.
+func (m *Mode) AddIgnoreToEOL(open string) {
+	m.ignores.addToEOL(open)
+}
+
+// PushMode saves the lexer's current ignore tokens, AutoEatWhitespace and IdentRule, replaces them with
+// the ones of mode and returns mode's Begin LexFn. This is typically used as the return value of a LexFn
+// that found the start of an embedded sub-language, e.g. return lexer.PushMode(actionMode).
+//
+// Every PushMode must be matched with a PopMode. If mode is nil an ErrModeStack error is reported and nil
+// is returned.
+func (lxr *Lexer) PushMode(mode *Mode) LexFn {
+	if mode == nil {
+		lxr.errorf(CodeModeStack, lxr.currentPos, "PushMode called with a nil mode")
+		return nil
+	}
+
+	lxr.logDebug("push mode %s", mode.Name)
+	lxr.modes = append(lxr.modes, savedMode{
+		mode:              lxr.mode,
+		autoEatWhitespace: lxr.AutoEatWhitespace,
+		identRule:         lxr.IdentRule,
+		ignores:           lxr.ignores,
+	})
+
+	lxr.mode = mode
+	lxr.AutoEatWhitespace = mode.AutoEatWhitespace
+	lxr.IdentRule = mode.IdentRule
+	lxr.ignores = mode.ignores.clone()
+
+	return mode.Begin
+}
+
+// PopMode restores the ignore tokens, AutoEatWhitespace and IdentRule that were active before the
+// matching PushMode and returns the Begin LexFn of the restored mode, or the lexer's begin LexFn when
+// returning to the outermost mode.
+//
+// If there is no mode to pop an ErrModeStack error is reported and nil is returned.
+func (lxr *Lexer) PopMode() LexFn {
+	if len(lxr.modes) == 0 {
+		lxr.errorf(CodeModeStack, lxr.currentPos, "PopMode called without a matching PushMode")
+		return nil
+	}
+
+	saved := lxr.modes[len(lxr.modes)-1]
+	lxr.modes = lxr.modes[:len(lxr.modes)-1]
+	lxr.logDebug("pop mode %s", lxr.ModeName())
+
+	lxr.mode = saved.mode
+	lxr.AutoEatWhitespace = saved.autoEatWhitespace
+	lxr.IdentRule = saved.identRule
+	lxr.ignores = saved.ignores
+
+	return lxr.modeBegin()
+}
+
+// modeBegin returns the Begin LexFn of the active mode or the lexer's begin LexFn if no mode has been
+// pushed.
+func (lxr *Lexer) modeBegin() LexFn {
+	if lxr.mode == nil {
+		return lxr.begin
+	}
+
+	return lxr.mode.Begin
+}
+
+// ModeName returns the Name of the active Mode or "" if no Mode has been pushed.
+func (lxr *Lexer) ModeName() string {
+	if lxr.mode == nil {
+		return ""
+	}
+
+	return lxr.mode.Name
+}
+
+// ModeDepth returns the number of pushed modes that have not been popped yet.
+func (lxr *Lexer) ModeDepth() int {
+	return len(lxr.modes)
+}
+
+// saveModes returns the active mode settings and a copy of the mode stack.
+func (lxr *Lexer) saveModes() modeState {
+	return modeState{
+		active: savedMode{
+			mode:              lxr.mode,
+			autoEatWhitespace: lxr.AutoEatWhitespace,
+			identRule:         lxr.IdentRule,
+			ignores:           lxr.ignores.clone(),
+		},
+		stack: cloneModeStack(lxr.modes),
+	}
+}
+
+// restoreModes makes the modes saved by saveModes active again if modes were pushed or popped since.
+// The saved ignore tokens are copied so that the same modeState can be restored more than once.
+func (lxr *Lexer) restoreModes(s modeState) {
+	if lxr.mode == s.active.mode && len(lxr.modes) == len(s.stack) {
+		return
+	}
+
+	lxr.mode = s.active.mode
+	lxr.AutoEatWhitespace = s.active.autoEatWhitespace
+	lxr.IdentRule = s.active.identRule
+	lxr.ignores = s.active.ignores.clone()
+	lxr.modes = cloneModeStack(s.stack)
+}
+
+// cloneModeStack returns a copy of stack whose ignore tokens can be changed without changing stack.
+func cloneModeStack(stack []savedMode) []savedMode {
+	c := make([]savedMode, len(stack))
+	for i, saved := range stack {
+		c[i] = saved
+		c[i].ignores = saved.ignores.clone()
+	}
+
+	return c
+}
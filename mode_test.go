@@ -0,0 +1,222 @@
+package goblex_test
+
+import (
+	"unicode"
+
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// templateLexer lexes text with {{ ... }} actions using a Mode for the actions.
+type templateLexer struct {
+	action *goblex.Mode
+	ops    *goblex.TokenSet
+}
+
+func newTemplateLexer() *templateLexer {
+	t := &templateLexer{ops: goblex.NewTokenSet("}}", "|")}
+
+	t.action = goblex.NewMode("action", t.lexAction)
+	t.action.AddIgnoreRange("/*", "*/", false)
+	t.action.IdentRule = goblex.IdentRule{
+		Start: func(ch rune) bool {
+			return ch == '.' || unicode.IsLetter(ch)
+		},
+		Continue: unicode.IsLetter,
+	}
+
+	return t
+}
+
+func (t *templateLexer) lexText(lexer *goblex.Lexer) goblex.LexFn {
+	if lexer.CaptureUntil(false, "{{") {
+		lexer.Emit(basicTokenType)
+		lexer.SkipCurrentToken(true)
+		return lexer.PushMode(t.action)
+	}
+
+	lexer.Emit(basicTokenType)
+	return nil
+}
+
+func (t *templateLexer) lexAction(lexer *goblex.Lexer) goblex.LexFn {
+	if lexer.CaptureIdent() {
+		lexer.Emit(identTokenType)
+		return t.lexAction
+	}
+
+	found, tkn := lexer.CurrentTokenIsIn(t.ops)
+	if !found {
+		return lexer.Errorf("unexpected input in action at %s", lexer.Position())
+	}
+
+	if tkn == "}}" {
+		next := lexer.PopMode()
+		lexer.SkipCurrentToken(true)
+		return next
+	}
+
+	lexer.ConsumeCurrentToken(true)
+	lexer.Emit(basicTokenType)
+	return t.lexAction
+}
+
+func (suite *GoblexTestSuite) lexTemplate(input string) []string {
+	t := newTemplateLexer()
+	l := goblex.NewLexer("template", input, t.lexText)
+	l.AutoEatWhitespace = false
+
+	var values []string
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+		values = append(values, token.String())
+	}
+
+	return values
+}
+
+func (suite *GoblexTestSuite) TestModeTemplate() {
+	suite.T().Parallel()
+
+	values := suite.lexTemplate("Hello {{ .Name /* c */ | upper }} and /* not a comment */ {{.Age}}!")
+
+	assert.Equal(suite.T(), []string{
+		"Hello ", ".Name", "|", "upper", " and /* not a comment */ ", ".Age", "!",
+	}, values)
+}
+
+func (suite *GoblexTestSuite) TestModeNested() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("modes", "", lexFun)
+	l.AddIgnoreTokens("#")
+
+	outer := goblex.NewMode("outer", lexFun)
+	outer.AutoEatWhitespace = false
+	outer.AddIgnoreTokens("%")
+	inner := goblex.NewMode("inner", lexFun)
+
+	assert.Equal(suite.T(), "", l.ModeName())
+
+	l.PushMode(outer)
+	assert.Equal(suite.T(), "outer", l.ModeName())
+	assert.False(suite.T(), l.AutoEatWhitespace)
+	l.AddIgnoreTokens("!")
+
+	l.PushMode(inner)
+	assert.Equal(suite.T(), "inner", l.ModeName())
+	assert.Equal(suite.T(), 2, l.ModeDepth())
+	assert.True(suite.T(), l.AutoEatWhitespace)
+
+	l.PopMode()
+	assert.Equal(suite.T(), "outer", l.ModeName())
+	assert.False(suite.T(), l.AutoEatWhitespace)
+
+	l.PopMode()
+	assert.Equal(suite.T(), "", l.ModeName())
+	assert.Equal(suite.T(), 0, l.ModeDepth())
+	assert.True(suite.T(), l.AutoEatWhitespace)
+
+	// the ignore token added while outer was active did not change outer
+	l2 := goblex.NewLexer("modes", "!%x#", func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureIdent()
+		lexer.Emit(basicTokenType)
+		return nil
+	})
+	l2.PushMode(outer)
+
+	assert.Equal(suite.T(), "", l2.NextEmittedToken().String())
+}
+
+func (suite *GoblexTestSuite) TestPopModeWithoutPush() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return lexer.PopMode()
+	}
+
+	l := goblex.NewLexer("modes", "abc", lexFun)
+	token := l.NextEmittedToken()
+
+	assert.Equal(suite.T(), goblex.TokenTypeError, token.Type())
+	assert.Equal(suite.T(), "PopMode called without a matching PushMode", token.String())
+}
+
+func (suite *GoblexTestSuite) TestPushNilMode() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return lexer.PushMode(nil)
+	}
+
+	l := goblex.NewLexer("modes", "abc", lexFun)
+	token := l.NextEmittedToken()
+
+	assert.Equal(suite.T(), goblex.TokenTypeError, token.Type())
+	assert.Equal(suite.T(), "PushMode called with a nil mode", token.String())
+	assert.Equal(suite.T(), 0, l.ModeDepth())
+}
+
+func (suite *GoblexTestSuite) TestRewindRestoresModes() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("modes", "a b", lexFun)
+	outer := goblex.NewMode("outer", lexFun)
+	inner := goblex.NewMode("inner", lexFun)
+	inner.AutoEatWhitespace = false
+
+	l.PushMode(outer)
+
+	m := l.Mark()
+	l.PushMode(inner)
+	assert.Equal(suite.T(), 2, l.ModeDepth())
+	l.Rewind(m)
+
+	assert.Equal(suite.T(), "outer", l.ModeName())
+	assert.Equal(suite.T(), 1, l.ModeDepth())
+	assert.True(suite.T(), l.AutoEatWhitespace)
+
+	m = l.Mark()
+	l.PopMode()
+	assert.Equal(suite.T(), 0, l.ModeDepth())
+	l.Rewind(m)
+
+	assert.Equal(suite.T(), "outer", l.ModeName())
+	assert.Equal(suite.T(), 1, l.ModeDepth())
+
+	l.PopMode()
+	assert.Equal(suite.T(), "", l.ModeName())
+}
+
+func (suite *GoblexTestSuite) TestRewindRestoresIgnoresAfterPushMode() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("modes", "x abc y #", lexFun)
+	l.AddIgnoreTokens("#")
+
+	m := l.Mark()
+	l.AddIgnoreTokens("abc")
+	l.PushMode(goblex.NewMode("inner", lexFun))
+	l.Rewind(m)
+
+	l.AddIgnoreTokens("zzz")
+	l.CaptureUntil(false, "#")
+
+	assert.Equal(suite.T(), "x abc y ", l.Flush())
+}
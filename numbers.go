@@ -0,0 +1,333 @@
+package goblex
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// NumberKind is the kind of numeric literal found by CaptureNumber.
+type NumberKind int
+
+const (
+	// NumberNone means no numeric literal was found at the current position
+	NumberNone NumberKind = iota
+	// NumberInvalid means a malformed numeric literal was found and an error token was emitted
+	NumberInvalid
+	// NumberDecimal is a decimal integer like 42
+	NumberDecimal
+	// NumberHex is a hexadecimal integer like 0x2A
+	NumberHex
+	// NumberOctal is an octal integer like 0o52 or, with LegacyOctal, 052
+	NumberOctal
+	// NumberBinary is a binary integer like 0b101010
+	NumberBinary
+	// NumberFloat is a decimal floating point number like 4.2 or 42e-1
+	NumberFloat
+)
+
+var numberKindNames = map[NumberKind]string{
+	NumberNone:    "none",
+	NumberInvalid: "invalid",
+	NumberDecimal: "decimal",
+	NumberHex:     "hexadecimal",
+	NumberOctal:   "octal",
+	NumberBinary:  "binary",
+	NumberFloat:   "float",
+}
+
+// String returns the name of the kind of number
+func (k NumberKind) String() string {
+	if name, ok := numberKindNames[k]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("NumberKind(%d)", int(k))
+}
+
+// NumberFormat configures which numeric literals are recognised by CaptureNumber.
+//
+// Decimal integers are always recognised.
+type NumberFormat struct {
+	// Hex enables 0x prefixed hexadecimal integers
+	Hex bool
+	// Octal enables 0o prefixed octal integers
+	Octal bool
+	// LegacyOctal treats decimal integers with a leading 0 like 0755 as octal
+	LegacyOctal bool
+	// Binary enables 0b prefixed binary integers
+	Binary bool
+	// Float enables decimal numbers with a fractional part like 1.5
+	Float bool
+	// LeadingDot enables floats without an integer part like .5
+	LeadingDot bool
+	// Exponent enables decimal exponents like 1e10 or 1.5E-3
+	Exponent bool
+	// NoLeadingZero rejects decimal numbers other than 0 that start with a 0 like 01
+	NoLeadingZero bool
+	// NoTrailingDot rejects floats without digits after the decimal point like 1. or 1.e5
+	NoTrailingDot bool
+	// Separator is a rune that may be used between digits like the '_' in 1_000_000. 0 disables it.
+	Separator rune
+}
+
+var (
+	// GoNumberFormat recognises the numeric literals of the Go language (excluding hex floats and
+	// imaginary numbers)
+	GoNumberFormat = NumberFormat{
+		Hex:         true,
+		Octal:       true,
+		LegacyOctal: true,
+		Binary:      true,
+		Float:       true,
+		LeadingDot:  true,
+		Exponent:    true,
+		Separator:   '_',
+	}
+
+	// CNumberFormat recognises the numeric literals of the C language (excluding hex floats and
+	// suffixes)
+	CNumberFormat = NumberFormat{
+		Hex:         true,
+		LegacyOctal: true,
+		Float:       true,
+		LeadingDot:  true,
+		Exponent:    true,
+	}
+
+	// JSONNumberFormat recognises the numbers allowed in JSON (excluding the sign)
+	JSONNumberFormat = NumberFormat{
+		Float:         true,
+		Exponent:      true,
+		NoLeadingZero: true,
+		NoTrailingDot: true,
+	}
+)
+
+// CaptureNumber reads a numeric literal as configured by the lexer's NumberFormat from the input stream,
+// writes it to the capture buffer and returns the kind of number that was found.
+//
+// If the current position does not start a number, nothing is captured and NumberNone is returned.
+// If the number is malformed, e.g. "0x" or "1e+", an ErrMalformedNumber error is reported and
+// NumberInvalid is returned. The malformed text is left in the capture buffer.
+//
+// Signs are not part of the literal and should be lexed as operators. Whitespace before and after the
+// number is discarded if AutoEatWhitespace is set.
+func (lxr *Lexer) CaptureNumber() NumberKind {
+	return lxr.CaptureNumberFormat(lxr.NumberFormat)
+}
+
+// CaptureNumberFormat does the same thing as CaptureNumber but uses the given format instead of the
+// lexer's NumberFormat.
+func (lxr *Lexer) CaptureNumberFormat(format NumberFormat) NumberKind {
+	lxr.enterDebug("CaptureNumber")
+	lxr.eatLeading()
+
+	kind := lxr.captureNumber(format)
+	lxr.logDebug("found number kind %s", kind)
+
+	lxr.eatTrailing()
+	lxr.exitDebug("CaptureNumber")
+	return kind
+}
+
+func (lxr *Lexer) captureNumber(f NumberFormat) NumberKind {
+	if lxr.eof {
+		return NumberNone
+	}
+
+	next := lxr.peek(1)
+	startsWithDot := f.Float && f.LeadingDot && lxr.currentRune == '.' && len(next) > 0 && isDecimal(next[0])
+	if !isDecimal(lxr.currentRune) && !startsWithDot {
+		return NumberNone
+	}
+
+	num := &numberCapture{lxr: lxr, format: f, start: lxr.currentPos}
+
+	if lxr.currentRune == '0' && len(next) > 0 {
+		switch {
+		case f.Hex && (next[0] == 'x' || next[0] == 'X'):
+			return num.prefixed(NumberHex, isHex)
+		case f.Binary && (next[0] == 'b' || next[0] == 'B'):
+			return num.prefixed(NumberBinary, isBinary)
+		case f.Octal && (next[0] == 'o' || next[0] == 'O'):
+			return num.prefixed(NumberOctal, isOctal)
+		}
+	}
+
+	return num.decimal()
+}
+
+// numberCapture holds the state of a single call to CaptureNumber.
+type numberCapture struct {
+	lxr    *Lexer
+	format NumberFormat
+	start  Position
+	text   []rune
+}
+
+func (n *numberCapture) capture() {
+	n.text = append(n.text, n.lxr.currentRune)
+	n.lxr.capture()
+	n.lxr.read()
+}
+
+// digits captures digits accepted by isDigit along with any separators between them and returns the
+// number of digits captured or an error message.
+func (n *numberCapture) digits(isDigit func(rune) bool, afterPrefix bool) (int, string) {
+	count := 0
+	for !n.lxr.eof {
+		ch := n.lxr.currentRune
+		if isDigit(ch) {
+			count++
+			n.capture()
+			continue
+		}
+
+		if n.format.Separator == 0 || ch != n.format.Separator {
+			break
+		}
+
+		next := n.lxr.peek(1)
+		if (count == 0 && !afterPrefix) || len(next) == 0 || !isDigit(next[0]) {
+			n.capture()
+			return count, fmt.Sprintf("%q must separate successive digits", n.format.Separator)
+		}
+		n.capture()
+	}
+
+	return count, ""
+}
+
+func (n *numberCapture) prefixed(kind NumberKind, isDigit func(rune) bool) NumberKind {
+	// the 0 and the prefix letter
+	n.capture()
+	n.capture()
+
+	count, msg := n.digits(isDigit, true)
+	if msg != "" {
+		return n.malformed(msg)
+	}
+
+	if count == 0 {
+		return n.malformed(fmt.Sprintf("%s literal has no digits", kind))
+	}
+
+	return n.finish(kind)
+}
+
+func (n *numberCapture) decimal() NumberKind {
+	kind := NumberDecimal
+	intDigits, msg := n.digits(isDecimal, false)
+	if msg != "" {
+		return n.malformed(msg)
+	}
+
+	if n.format.Float && n.lxr.currentRune == '.' && !n.lxr.eof && n.acceptDot(intDigits) {
+		kind = NumberFloat
+		n.capture()
+		fracDigits, msg := n.digits(isDecimal, false)
+		if msg != "" {
+			return n.malformed(msg)
+		}
+
+		if intDigits == 0 && fracDigits == 0 {
+			return n.malformed("float literal has no digits")
+		}
+
+		if fracDigits == 0 && n.format.NoTrailingDot {
+			return n.malformed("float literal has no digits after the decimal point")
+		}
+	}
+
+	if n.format.Exponent && (n.lxr.currentRune == 'e' || n.lxr.currentRune == 'E') && !n.lxr.eof {
+		kind = NumberFloat
+		n.capture()
+		if n.lxr.currentRune == '+' || n.lxr.currentRune == '-' {
+			n.capture()
+		}
+
+		expDigits, msg := n.digits(isDecimal, false)
+		if msg != "" {
+			return n.malformed(msg)
+		}
+
+		if expDigits == 0 {
+			return n.malformed("exponent has no digits")
+		}
+	}
+
+	if intDigits > 1 && n.text[0] == '0' {
+		switch {
+		case kind == NumberDecimal && n.format.LegacyOctal:
+			for _, ch := range n.text[1:] {
+				if ch != n.format.Separator && !isOctal(ch) {
+					return n.malformed(fmt.Sprintf("invalid digit %q in octal literal", ch))
+				}
+			}
+			kind = NumberOctal
+		case n.format.NoLeadingZero:
+			return n.malformed("leading zeros are not allowed")
+		}
+	}
+
+	return n.finish(kind)
+}
+
+// acceptDot returns whether the '.' at the current position is a decimal point rather than e.g. a
+// member access or range operator following an integer.
+func (n *numberCapture) acceptDot(intDigits int) bool {
+	next := n.lxr.peek(1)
+	if len(next) == 0 {
+		return intDigits > 0
+	}
+
+	switch {
+	case isDecimal(next[0]):
+		return true
+	case next[0] == '.' || next[0] == '_':
+		return false
+	case next[0] == 'e' || next[0] == 'E':
+		return intDigits > 0 && n.format.Exponent
+	case unicode.IsLetter(next[0]):
+		return false
+	}
+
+	return intDigits > 0
+}
+
+// finish makes sure the number is not directly followed by more ident characters like in "0x1g" or
+// "12abc".
+func (n *numberCapture) finish(kind NumberKind) NumberKind {
+	ch := n.lxr.currentRune
+	if n.lxr.eof || !(unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_') {
+		return kind
+	}
+
+	msg := fmt.Sprintf("invalid character %q in %s literal", ch, kind)
+	for !n.lxr.eof && (unicode.IsLetter(n.lxr.currentRune) || unicode.IsDigit(n.lxr.currentRune) || n.lxr.currentRune == '_') {
+		n.capture()
+	}
+
+	return n.malformed(msg)
+}
+
+func (n *numberCapture) malformed(msg string) NumberKind {
+	n.lxr.errorf(CodeMalformedNumber, n.start, "malformed number %q: %s", string(n.text), msg)
+	return NumberInvalid
+}
+
+func isDecimal(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isHex(ch rune) bool {
+	return isDecimal(ch) || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+func isOctal(ch rune) bool {
+	return ch >= '0' && ch <= '7'
+}
+
+func isBinary(ch rune) bool {
+	return ch == '0' || ch == '1'
+}
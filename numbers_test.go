@@ -0,0 +1,176 @@
+package goblex_test
+
+import (
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type numberResult struct {
+	kind  goblex.NumberKind
+	value string
+}
+
+func (suite *GoblexTestSuite) lexNumbers(input string, format goblex.NumberFormat) ([]numberResult, []string) {
+	var results []numberResult
+	var errs []string
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		kind := lexer.CaptureNumber()
+		if kind == goblex.NumberNone {
+			return nil
+		}
+
+		results = append(results, numberResult{kind: kind, value: lexer.Flush()})
+		if lexer.CurrentTokenIs(",") {
+			lexer.CaptureUntil(true, ",")
+			lexer.SkipCurrentToken(true)
+		}
+		return lexFun
+	}
+
+	l := goblex.NewLexer("numbers", input, lexFun)
+	l.NumberFormat = format
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		if token.Type() == goblex.TokenTypeError {
+			errs = append(errs, token.String())
+		}
+	}
+
+	return results, errs
+}
+
+func (suite *GoblexTestSuite) TestCaptureNumberGo() {
+	suite.T().Parallel()
+
+	results, errs := suite.lexNumbers("42, 0x2A, 0o52, 052, 0b10_1010, 4.2, .5, 1., 1e10, 1.5E-3, 1_000_000, 0", goblex.GoNumberFormat)
+
+	assert.Empty(suite.T(), errs)
+	assert.Equal(suite.T(), []numberResult{
+		{goblex.NumberDecimal, "42"},
+		{goblex.NumberHex, "0x2A"},
+		{goblex.NumberOctal, "0o52"},
+		{goblex.NumberOctal, "052"},
+		{goblex.NumberBinary, "0b10_1010"},
+		{goblex.NumberFloat, "4.2"},
+		{goblex.NumberFloat, ".5"},
+		{goblex.NumberFloat, "1."},
+		{goblex.NumberFloat, "1e10"},
+		{goblex.NumberFloat, "1.5E-3"},
+		{goblex.NumberDecimal, "1_000_000"},
+		{goblex.NumberDecimal, "0"},
+	}, results)
+}
+
+func (suite *GoblexTestSuite) TestCaptureNumberMalformed() {
+	suite.T().Parallel()
+
+	inputs := map[string]string{
+		"0x":      `malformed number "0x": hexadecimal literal has no digits`,
+		"0b102":   `malformed number "0b102": invalid character '2' in binary literal`,
+		"1e+":     `malformed number "1e+": exponent has no digits`,
+		"1_":      `malformed number "1_": '_' must separate successive digits`,
+		"1__0":    `malformed number "1_": '_' must separate successive digits`,
+		"09":      `malformed number "09": invalid digit '9' in octal literal`,
+		"12abc":   `malformed number "12abc": invalid character 'a' in decimal literal`,
+		"0x1g":    `malformed number "0x1g": invalid character 'g' in hexadecimal literal`,
+		"0o":      `malformed number "0o": octal literal has no digits`,
+		"1.5e-x1": `malformed number "1.5e-": exponent has no digits`,
+	}
+
+	for input, expected := range inputs {
+		results, errs := suite.lexNumbers(input, goblex.GoNumberFormat)
+
+		suite.Require().Len(results, 1, input)
+		assert.Equal(suite.T(), goblex.NumberInvalid, results[0].kind, input)
+		assert.Equal(suite.T(), []string{expected}, errs, input)
+	}
+}
+
+func (suite *GoblexTestSuite) TestCaptureNumberFormats() {
+	suite.T().Parallel()
+
+	results, errs := suite.lexNumbers("0.5, 1e3, 10", goblex.JSONNumberFormat)
+	assert.Empty(suite.T(), errs)
+	assert.Equal(suite.T(), []numberResult{
+		{goblex.NumberFloat, "0.5"},
+		{goblex.NumberFloat, "1e3"},
+		{goblex.NumberDecimal, "10"},
+	}, results)
+
+	_, errs = suite.lexNumbers("01", goblex.JSONNumberFormat)
+	assert.Equal(suite.T(), []string{`malformed number "01": leading zeros are not allowed`}, errs)
+
+	for _, input := range []string{"1.", "1.e5"} {
+		results, errs = suite.lexNumbers(input, goblex.JSONNumberFormat)
+		assert.Equal(suite.T(), []numberResult{{goblex.NumberInvalid, "1."}}, results, input)
+		assert.Equal(suite.T(), []string{`malformed number "1.": float literal has no digits after the decimal point`}, errs, input)
+	}
+
+	_, errs = suite.lexNumbers("0b1", goblex.CNumberFormat)
+	assert.Equal(suite.T(), []string{`malformed number "0b1": invalid character 'b' in decimal literal`}, errs)
+
+	results, errs = suite.lexNumbers("1_000", goblex.NumberFormat{})
+	assert.Equal(suite.T(), []numberResult{{goblex.NumberInvalid, "1_000"}}, results)
+	assert.Len(suite.T(), errs, 1)
+}
+
+func (suite *GoblexTestSuite) TestCaptureNumberStopsAtOperators() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("numbers", "  x 1..5 3.String 7-2", lexFun)
+
+	assert.Equal(suite.T(), goblex.NumberNone, l.CaptureNumber())
+	l.CaptureIdent()
+	l.Flush()
+
+	assert.Equal(suite.T(), goblex.NumberDecimal, l.CaptureNumber())
+	assert.Equal(suite.T(), "1", l.Flush())
+	assert.True(suite.T(), l.CurrentTokenIs(".."))
+	l.CaptureUntil(true, "5")
+	l.SkipCurrentToken(true)
+
+	assert.Equal(suite.T(), goblex.NumberDecimal, l.CaptureNumberFormat(goblex.CNumberFormat))
+	assert.Equal(suite.T(), "3", l.Flush())
+	assert.True(suite.T(), l.CurrentTokenIs(".String"))
+	l.CaptureUntil(true, "7")
+
+	l.Flush()
+	assert.Equal(suite.T(), goblex.NumberDecimal, l.CaptureNumber())
+	assert.Equal(suite.T(), "7", l.Flush())
+	assert.True(suite.T(), l.CurrentTokenIs("-"))
+}
+
+func (suite *GoblexTestSuite) TestNumberKindString() {
+	suite.T().Parallel()
+
+	assert.Equal(suite.T(), "hexadecimal", goblex.NumberHex.String())
+	assert.Equal(suite.T(), "NumberKind(42)", goblex.NumberKind(42).String())
+}
+
+func (suite *GoblexTestSuite) TestCaptureNumberMalformedPosition() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureNumber()
+		return nil
+	}
+
+	l := goblex.NewLexer("numbers", "  0x1g", lexFun)
+	l.NumberFormat = goblex.GoNumberFormat
+
+	token := l.NextEmittedToken().(goblex.ErrorToken)
+	var lexErr *goblex.LexError
+	suite.Require().ErrorAs(token.Err(), &lexErr)
+	assert.Equal(suite.T(), goblex.Position{Offset: 2, Line: 1, Column: 3}, lexErr.Pos)
+}
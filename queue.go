@@ -0,0 +1,106 @@
+package goblex
+
+// tokenQueue is an unbounded FIFO queue of emitted tokens.
+//
+// A LexFn may emit any number of tokens in a single step, so the queue grows as needed instead of
+// blocking the emitter. E is Token for a Lexer and the user's own token type for a TypedLexer.
+type tokenQueue[E any] struct {
+	items  []E
+	head   int
+	pushed int
+}
+
+func (q *tokenQueue[E]) push(token E) {
+	q.items = append(q.items, token)
+	q.pushed++
+}
+
+// truncate discards the queued tokens that were pushed after the first n pushes. Tokens that have
+// already been popped are not affected.
+func (q *tokenQueue[E]) truncate(n int) {
+	var zero E
+	for q.pushed > n && len(q.items) > q.head {
+		q.items[len(q.items)-1] = zero
+		q.items = q.items[:len(q.items)-1]
+		q.pushed--
+	}
+}
+
+func (q *tokenQueue[E]) pop() (E, bool) {
+	var zero E
+	if q.head >= len(q.items) {
+		return zero, false
+	}
+
+	token := q.items[q.head]
+	q.items[q.head] = zero
+	q.head++
+
+	if q.head == len(q.items) {
+		// drained, reuse the backing array
+		q.items = q.items[:0]
+		q.head = 0
+	} else if q.head > len(q.items)/2 {
+		// more than half of the backing array is consumed, compact it
+		n := copy(q.items, q.items[q.head:])
+		for i := n; i < len(q.items); i++ {
+			q.items[i] = zero
+		}
+		q.items = q.items[:n]
+		q.head = 0
+	}
+
+	return token, true
+}
+
+// tokenSink receives the tokens emitted by a Lexer wrapped by a TypedLexer in place of the lexer's own
+// token queue.
+type tokenSink interface {
+	// pushInfo queues a token created by the lexer itself, e.g. by Emit or Errorf
+	pushInfo(info TokenInfo)
+	// pushToken queues a token passed to EmitToken
+	pushToken(token Token)
+	// pushedCount returns the number of tokens pushed so far
+	pushedCount() int
+	// truncate discards the queued tokens that were pushed after the first n pushes
+	truncate(n int)
+}
+
+// pushToken queues a token passed to EmitToken.
+func (lxr *Lexer) pushToken(token Token) {
+	if lxr.sink != nil {
+		lxr.sink.pushToken(token)
+		return
+	}
+
+	lxr.tokens.push(token)
+}
+
+// pushDefault queues a token created by the lexer itself.
+func (lxr *Lexer) pushDefault(token defaultToken) {
+	if lxr.sink != nil {
+		lxr.sink.pushInfo(token.info())
+		return
+	}
+
+	lxr.tokens.push(token)
+}
+
+// pushedTokens returns the number of tokens pushed so far.
+func (lxr *Lexer) pushedTokens() int {
+	if lxr.sink != nil {
+		return lxr.sink.pushedCount()
+	}
+
+	return lxr.tokens.pushed
+}
+
+// truncateTokens discards the queued tokens that were pushed after the first n pushes.
+func (lxr *Lexer) truncateTokens(n int) {
+	if lxr.sink != nil {
+		lxr.sink.truncate(n)
+		return
+	}
+
+	lxr.tokens.truncate(n)
+}
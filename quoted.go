@@ -0,0 +1,289 @@
+package goblex
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// EscapeStyle selects how escape sequences are recognised inside quoted strings by CaptureQuoted.
+type EscapeStyle int
+
+const (
+	// EscapeNone recognises no escape sequences at all, e.g. Go raw strings. Newlines are allowed.
+	EscapeNone EscapeStyle = iota
+	// EscapeGo recognises the backslash escapes of Go interpreted string literals.
+	EscapeGo
+	// EscapeJSON recognises the backslash escapes of JSON strings including UTF-16 surrogate pairs.
+	EscapeJSON
+	// EscapeC recognises the backslash escapes of C string literals.
+	EscapeC
+	// EscapeSQL recognises a doubled quote as an escaped quote like in 'it''s'. Newlines are allowed.
+	EscapeSQL
+)
+
+var simpleEscapes = map[EscapeStyle]map[rune]string{
+	EscapeGo: {
+		'a': "\a", 'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v",
+		'\\': "\\", '\'': "'", '"': "\"",
+	},
+	EscapeJSON: {
+		'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t",
+		'\\': "\\", '/': "/", '"': "\"",
+	},
+	EscapeC: {
+		'a': "\a", 'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v",
+		'\\': "\\", '\'': "'", '"': "\"", '?': "?",
+	},
+}
+
+// CaptureQuoted reads a string literal delimited by quote from the input stream, writes it to the
+// capture buffer and returns whether a complete, valid literal was captured.
+//
+// Escape sequences are recognised according to style so an escaped quote does not end the literal.
+// If decode is false the literal is captured exactly as it appears in the input including the quotes.
+// If decode is true only the contents are captured with all escape sequences replaced by the text they
+// represent, although the captured span still includes the quotes.
+//
+// If the current position is not on quote nothing is captured and false is returned. Unterminated
+// literals and invalid escape sequences are reported as ErrUnterminatedString and ErrInvalidEscape
+// errors positioned at the opening quote and the backslash of the escape sequence respectively.
+//
+// Whitespace before and after the literal is discarded if AutoEatWhitespace is set, but whitespace and
+// ignore tokens inside the literal are always captured.
+func (lxr *Lexer) CaptureQuoted(quote string, style EscapeStyle, decode bool) bool {
+	lxr.enterDebug("CaptureQuoted")
+	lxr.eatLeading()
+	if quote == "" || !lxr.CurrentTokenIs(quote) {
+		lxr.exitDebug("CaptureQuoted")
+		return false
+	}
+
+	q := &quotedCapture{
+		lxr:      lxr,
+		quote:    quote,
+		quoteLen: utf8.RuneCountInString(quote),
+		style:    style,
+		decode:   decode,
+		start:    lxr.currentPos,
+	}
+
+	ok := q.run()
+	if ok {
+		lxr.eatTrailing()
+	}
+
+	lxr.exitDebug("CaptureQuoted")
+	return ok
+}
+
+// quotedCapture holds the state of a single call to CaptureQuoted.
+type quotedCapture struct {
+	lxr      *Lexer
+	quote    string
+	quoteLen int
+	style    EscapeStyle
+	decode   bool
+	start    Position
+	escape   []rune
+}
+
+func (q *quotedCapture) run() bool {
+	lxr := q.lxr
+	valid := true
+
+	q.delimiter()
+	for {
+		if lxr.eof || (lxr.currentRune == '\n' && q.style != EscapeNone && q.style != EscapeSQL) {
+			lxr.errorf(CodeUnterminatedString, q.start, "unterminated string")
+			return false
+		}
+
+		if q.style == EscapeSQL && lxr.CurrentTokenIs(q.quote+q.quote) {
+			escStart := lxr.currentPos
+			for i := 0; i < 2*q.quoteLen; i++ {
+				q.escapeRune()
+			}
+			if q.decode {
+				lxr.captureString(q.quote, escStart)
+			}
+			continue
+		}
+
+		if lxr.CurrentTokenIs(q.quote) {
+			q.delimiter()
+			return valid
+		}
+
+		if lxr.currentRune == '\\' && simpleEscapes[q.style] != nil {
+			if !q.unescape() {
+				valid = false
+			}
+			continue
+		}
+
+		lxr.capture()
+		lxr.read()
+	}
+}
+
+// delimiter consumes a quote, capturing it unless decoding. When decoding, the captured span is still
+// extended to include it.
+func (q *quotedCapture) delimiter() {
+	for i := 0; i < q.quoteLen; i++ {
+		if !q.decode {
+			q.lxr.capture()
+		}
+		q.lxr.read()
+	}
+
+	if q.decode {
+		q.lxr.captureString("", q.start)
+	}
+}
+
+// escapeRune consumes a rune that is part of an escape sequence, capturing it unless decoding.
+func (q *quotedCapture) escapeRune() {
+	q.escape = append(q.escape, q.lxr.currentRune)
+	if !q.decode {
+		q.lxr.capture()
+	}
+	q.lxr.read()
+}
+
+// unescape consumes the escape sequence at the current position and returns whether it was valid.
+func (q *quotedCapture) unescape() bool {
+	lxr := q.lxr
+	escStart := lxr.currentPos
+	q.escape = q.escape[:0]
+
+	// the backslash
+	q.escapeRune()
+	if lxr.eof {
+		return true
+	}
+
+	ch := lxr.currentRune
+	decoded, msg := "", ""
+
+	if s, ok := simpleEscapes[q.style][ch]; ok {
+		q.escapeRune()
+		decoded = s
+	} else {
+		switch {
+		case ch == 'u':
+			decoded, msg = q.unicodeEscape(4)
+		case ch == 'U' && q.style != EscapeJSON:
+			decoded, msg = q.unicodeEscape(8)
+		case ch == 'x' && q.style == EscapeGo:
+			decoded, msg = q.byteEscape(2, 2, 16)
+		case ch == 'x' && q.style == EscapeC:
+			decoded, msg = q.byteEscape(1, 8, 16)
+		case ch >= '0' && ch <= '7' && q.style == EscapeGo:
+			decoded, msg = q.byteEscape(3, 3, 8)
+		case ch >= '0' && ch <= '7' && q.style == EscapeC:
+			decoded, msg = q.byteEscape(1, 3, 8)
+		default:
+			q.escapeRune()
+			msg = "unknown escape sequence"
+		}
+	}
+
+	if msg != "" {
+		lxr.errorf(CodeInvalidEscape, escStart, "invalid escape sequence %q in string starting at %s: %s", string(q.escape), q.start, msg)
+		decoded = string(q.escape)
+	}
+
+	if q.decode {
+		lxr.captureString(decoded, escStart)
+	}
+
+	return msg == ""
+}
+
+// digits consumes between min and max digits of the given base and returns them.
+func (q *quotedCapture) digits(min, max, base int) (string, bool) {
+	var sb strings.Builder
+	for sb.Len() < max && !q.lxr.eof && isBaseDigit(q.lxr.currentRune, base) {
+		sb.WriteRune(q.lxr.currentRune)
+		q.escapeRune()
+	}
+
+	return sb.String(), sb.Len() >= min
+}
+
+// byteEscape decodes escapes like \xFF and \377 that represent a single byte.
+func (q *quotedCapture) byteEscape(min, max, base int) (string, string) {
+	if base == 16 {
+		// the x
+		q.escapeRune()
+	}
+
+	digits, ok := q.digits(min, max, base)
+	if !ok {
+		return "", "not enough digits"
+	}
+
+	v, err := strconv.ParseUint(digits, base, 64)
+	if err != nil || v > 0xFF {
+		return "", "value out of range"
+	}
+
+	return string([]byte{byte(v)}), ""
+}
+
+// unicodeEscape decodes escapes like \u00E9 and \U0001F984 that represent a unicode code point.
+func (q *quotedCapture) unicodeEscape(size int) (string, string) {
+	// the u or U
+	q.escapeRune()
+
+	digits, ok := q.digits(size, size, 16)
+	if !ok {
+		return "", "not enough digits"
+	}
+
+	v, _ := strconv.ParseUint(digits, 16, 32)
+	r := rune(v)
+
+	if q.style == EscapeJSON && utf16.IsSurrogate(r) {
+		return q.lowSurrogate(r)
+	}
+
+	if !utf8.ValidRune(r) {
+		return "", "invalid unicode code point"
+	}
+
+	return string(r), ""
+}
+
+// lowSurrogate decodes the \u escape following the high surrogate of a JSON UTF-16 surrogate pair.
+func (q *quotedCapture) lowSurrogate(high rune) (string, string) {
+	if !q.lxr.CurrentTokenIs("\\u") {
+		return "", "unpaired surrogate"
+	}
+
+	q.escapeRune()
+	q.escapeRune()
+
+	digits, ok := q.digits(4, 4, 16)
+	if !ok {
+		return "", "not enough digits"
+	}
+
+	v, _ := strconv.ParseUint(digits, 16, 32)
+	r := utf16.DecodeRune(high, rune(v))
+	if r == utf8.RuneError {
+		return "", "invalid surrogate pair"
+	}
+
+	return string(r), ""
+}
+
+func isBaseDigit(ch rune, base int) bool {
+	if base == 8 {
+		return isOctal(ch)
+	}
+
+	return isHex(ch)
+}
@@ -0,0 +1,150 @@
+package goblex_test
+
+import (
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *GoblexTestSuite) lexQuoted(input, quote string, style goblex.EscapeStyle, decode bool) (bool, string, []string) {
+	var errs []string
+	ok := false
+	value := ""
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		ok = lexer.CaptureQuoted(quote, style, decode)
+		value = lexer.Flush()
+		return nil
+	}
+
+	l := goblex.NewLexer("quoted", input, lexFun)
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		if token.Type() == goblex.TokenTypeError {
+			errs = append(errs, token.(goblex.ErrorToken).Err().Error())
+		}
+	}
+
+	return ok, value, errs
+}
+
+func (suite *GoblexTestSuite) TestCaptureQuotedEscapedQuote() {
+	suite.T().Parallel()
+
+	ok, raw, errs := suite.lexQuoted(`  "say \"hi\"" rest`, `"`, goblex.EscapeGo, false)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), `"say \"hi\""`, raw)
+	assert.Empty(suite.T(), errs)
+
+	ok, decoded, errs := suite.lexQuoted(`"say \"hi\"" rest`, `"`, goblex.EscapeGo, true)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), `say "hi"`, decoded)
+	assert.Empty(suite.T(), errs)
+}
+
+func (suite *GoblexTestSuite) TestCaptureQuotedDecodeStyles() {
+	suite.T().Parallel()
+
+	cases := []struct {
+		input    string
+		quote    string
+		style    goblex.EscapeStyle
+		expected string
+	}{
+		{`"tab\there\n\x41\101é\U0001F984\\"`, `"`, goblex.EscapeGo, "tab\there\nAAé🦄\\"},
+		{`"a\/bé🦄\""`, `"`, goblex.EscapeJSON, "a/bé🦄\""},
+		{`"what\?\x7\0\12"`, `"`, goblex.EscapeC, "what?\x07\x00\n"},
+		{`'it''s' rest`, `'`, goblex.EscapeSQL, "it's"},
+		{"'multi\nline'", `'`, goblex.EscapeSQL, "multi\nline"},
+		{"`raw \\n /* not a comment */\n`", "`", goblex.EscapeNone, "raw \\n /* not a comment */\n"},
+		{`"""triple " quoted"""`, `"""`, goblex.EscapeGo, `triple " quoted`},
+	}
+
+	for _, c := range cases {
+		ok, value, errs := suite.lexQuoted(c.input, c.quote, c.style, true)
+
+		assert.True(suite.T(), ok, c.input)
+		assert.Equal(suite.T(), c.expected, value, c.input)
+		assert.Empty(suite.T(), errs, c.input)
+	}
+}
+
+func (suite *GoblexTestSuite) TestCaptureQuotedUnterminated() {
+	suite.T().Parallel()
+
+	ok, _, errs := suite.lexQuoted(`    "never closed \"`, `"`, goblex.EscapeGo, true)
+	assert.False(suite.T(), ok)
+	assert.Equal(suite.T(), []string{"1:5: unterminated string"}, errs)
+
+	ok, _, errs = suite.lexQuoted("\n  \"no newlines\n\"", `"`, goblex.EscapeJSON, false)
+	assert.False(suite.T(), ok)
+	assert.Equal(suite.T(), []string{"2:3: unterminated string"}, errs)
+
+	ok, _, errs = suite.lexQuoted(`'it''`, `'`, goblex.EscapeSQL, false)
+	assert.False(suite.T(), ok)
+	assert.Equal(suite.T(), []string{"1:1: unterminated string"}, errs)
+}
+
+func (suite *GoblexTestSuite) TestCaptureQuotedInvalidEscape() {
+	suite.T().Parallel()
+
+	ok, value, errs := suite.lexQuoted(`"a\qb\x4" tail`, `"`, goblex.EscapeGo, true)
+	assert.False(suite.T(), ok)
+	assert.Equal(suite.T(), `a\qb\x4`, value)
+	assert.Equal(suite.T(), []string{
+		`1:3: invalid escape sequence "\\q" in string starting at 1:1: unknown escape sequence`,
+		`1:6: invalid escape sequence "\\x4" in string starting at 1:1: not enough digits`,
+	}, errs)
+
+	ok, _, errs = suite.lexQuoted(`"\ud83e"`, `"`, goblex.EscapeJSON, true)
+	assert.False(suite.T(), ok)
+	assert.Len(suite.T(), errs, 1)
+
+	ok, _, errs = suite.lexQuoted(`"\U00110000"`, `"`, goblex.EscapeGo, true)
+	assert.False(suite.T(), ok)
+	assert.Len(suite.T(), errs, 1)
+}
+
+func (suite *GoblexTestSuite) TestCaptureQuotedIgnoresInside() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("quoted", `no "a // b  /* c */" /* d */ next`, lexFun)
+	l.AddIgnoreRange("/*", "*/", false)
+	l.AddIgnoreToEOL("//")
+
+	assert.False(suite.T(), l.CaptureQuoted(`"`, goblex.EscapeGo, false))
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "no", l.Flush())
+
+	assert.True(suite.T(), l.CaptureQuoted(`"`, goblex.EscapeGo, true))
+	assert.Equal(suite.T(), "a // b  /* c */", l.Flush())
+
+	l.CaptureIdent()
+	assert.Equal(suite.T(), "next", l.Flush())
+}
+
+func (suite *GoblexTestSuite) TestCaptureQuotedPosition() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureQuoted(`"`, goblex.EscapeGo, true)
+		lexer.Emit(basicTokenType)
+		return nil
+	}
+
+	l := goblex.NewLexer("quoted", `  "\"x\""`, lexFun)
+	token := l.NextEmittedToken().(goblex.PositionedToken)
+
+	assert.Equal(suite.T(), `"x"`, token.String())
+	// the span covers the whole literal including the quotes even when decoding
+	assert.Equal(suite.T(), 2, token.Start().Offset)
+	assert.Equal(suite.T(), 9, token.End().Offset)
+}
@@ -0,0 +1,83 @@
+package goblex
+
+// Recovery configures how the lexer recovers from errors instead of stopping at the first one.
+//
+// When set on a Lexer, Errorf returns a LexFn that skips the input up to the next of SyncTokens and then
+// continues lexing with Resume, so a single bad character no longer ends the LexFn chain.
+type Recovery struct {
+	// SyncTokens are the tokens at which lexing resumes after an error, e.g. ";" or "\n". The sync token
+	// itself is not skipped. If empty, lexing resumes at the next rune.
+	SyncTokens []string
+	// Resume is the LexFn lexing continues with after an error. defaults to the Begin LexFn of the active
+	// mode or the lexer's begin LexFn if no mode has been pushed
+	Resume LexFn
+	// MaxErrors is the number of errors after which lexing stops, reporting a final ErrTooManyErrors
+	// error. defaults to 0 which never stops lexing
+	MaxErrors int
+}
+
+// RecoverTo returns a LexFn that discards the capture buffer, skips the input up to but not including
+// the next of syncTokens, or to the end of the input if none is found, and then returns next. If no
+// syncTokens are given, lexing resumes at the next rune.
+//
+// This is typically used as the return value of a LexFn that reported an error, e.g.
+//
+//	lexer.Errorf("unexpected %q", ch)
+//	return lexer.RecoverTo(lexStatement, ";")
+//
+// To guarantee progress, at least one rune is skipped if the lexer has not moved since the last time it
+// recovered. If the lexer is already at the end of the input no progress is possible, so the LexFn
+// chain ends instead of returning next.
+func (lxr *Lexer) RecoverTo(next LexFn, syncTokens ...string) LexFn {
+	return func(lexer *Lexer) LexFn {
+		lexer.enterDebug("RecoverTo")
+		lexer.resetCapture()
+
+		if lexer.eof {
+			lexer.logDebug("nothing left to recover at %s", lexer.currentPos)
+			lexer.exitDebug("RecoverTo")
+			return nil
+		}
+
+		if len(syncTokens) == 0 || lexer.currentPos == lexer.recoverPos {
+			lexer.read()
+		}
+
+		for len(syncTokens) > 0 && !lexer.eof {
+			if found, _ := lexer.CurrentTokenIsOneOf(syncTokens...); found {
+				break
+			}
+			lexer.read()
+		}
+
+		lexer.recoverPos = lexer.currentPos
+		lexer.logDebug("recovered at %s", lexer.currentPos)
+		lexer.exitDebug("RecoverTo")
+		return next
+	}
+}
+
+// recoveryFn returns the LexFn Errorf returns when Recovery is set.
+func (lxr *Lexer) recoveryFn() LexFn {
+	if lxr.Recovery == nil || lxr.stopped {
+		return nil
+	}
+
+	resume := lxr.Recovery.Resume
+	if resume == nil {
+		resume = lxr.modeBegin()
+	}
+
+	return lxr.RecoverTo(resume, lxr.Recovery.SyncTokens...)
+}
+
+// countError counts a reported error and stops lexing once Recovery's MaxErrors is reached.
+func (lxr *Lexer) countError() {
+	lxr.errCount++
+	if lxr.Recovery == nil || lxr.Recovery.MaxErrors < 1 || lxr.errCount != lxr.Recovery.MaxErrors {
+		return
+	}
+
+	lxr.stopped = true
+	lxr.reportError(&LexError{Pos: lxr.currentPos, Msg: ErrTooManyErrors.Msg, Code: CodeTooManyErrors})
+}
@@ -0,0 +1,184 @@
+package goblex_test
+
+import (
+	"errors"
+
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *GoblexTestSuite) lexStatements(input string, setup func(l *goblex.Lexer)) []string {
+	var lexFun goblex.LexFn
+	semi := goblex.NewTokenSet(";")
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.IsEOF() {
+			return nil
+		}
+
+		if lexer.CaptureIdent() {
+			lexer.Emit(identTokenType)
+			return lexFun
+		}
+
+		if found, _ := lexer.CurrentTokenIsIn(semi); found {
+			lexer.SkipCurrentToken(true)
+			return lexFun
+		}
+
+		return lexer.Errorf("unexpected input")
+	}
+
+	l := goblex.NewLexer("statements", input, lexFun)
+	setup(l)
+
+	var values []string
+	for {
+		token := l.NextEmittedToken()
+		switch token.Type() {
+		case goblex.TokenTypeEOF:
+			return values
+		case goblex.TokenTypeError:
+			values = append(values, "ERROR "+token.(goblex.ErrorToken).Err().Error())
+		default:
+			values = append(values, token.String())
+		}
+	}
+}
+
+func (suite *GoblexTestSuite) TestRecoveryDisabled() {
+	suite.T().Parallel()
+
+	values := suite.lexStatements("a; b$c; d; #e; f", func(l *goblex.Lexer) {})
+
+	assert.Equal(suite.T(), []string{"a", "b", "ERROR 1:5: unexpected input"}, values)
+}
+
+func (suite *GoblexTestSuite) TestRecoverySyncTokens() {
+	suite.T().Parallel()
+
+	values := suite.lexStatements("a; b$c; d; #e; f", func(l *goblex.Lexer) {
+		l.Recovery = &goblex.Recovery{SyncTokens: []string{";"}}
+	})
+
+	assert.Equal(suite.T(), []string{
+		"a", "b", "ERROR 1:5: unexpected input", "d", "ERROR 1:12: unexpected input", "f",
+	}, values)
+}
+
+func (suite *GoblexTestSuite) TestRecoveryNextRune() {
+	suite.T().Parallel()
+
+	values := suite.lexStatements("a$%b", func(l *goblex.Lexer) {
+		l.Recovery = &goblex.Recovery{}
+	})
+
+	assert.Equal(suite.T(), []string{
+		"a", "ERROR 1:2: unexpected input", "ERROR 1:3: unexpected input", "b",
+	}, values)
+}
+
+func (suite *GoblexTestSuite) TestRecoveryMaxErrors() {
+	suite.T().Parallel()
+
+	var l *goblex.Lexer
+	values := suite.lexStatements("$ $ $ $ a", func(lexer *goblex.Lexer) {
+		l = lexer
+		l.Recovery = &goblex.Recovery{MaxErrors: 2}
+	})
+
+	assert.Equal(suite.T(), []string{
+		"ERROR 1:1: unexpected input", "ERROR 1:3: unexpected input", "ERROR 1:3: too many errors",
+	}, values)
+	assert.True(suite.T(), errors.Is(l.LastErr(), goblex.ErrTooManyErrors))
+}
+
+func (suite *GoblexTestSuite) TestRecoverToResume() {
+	suite.T().Parallel()
+
+	var lexFun, lexAfterError goblex.LexFn
+
+	lexAfterError = func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureIdent()
+		lexer.Emit(basicTokenType)
+		return nil
+	}
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureUntil(true, "!")
+		lexer.Errorf("bang")
+		return lexer.RecoverTo(lexAfterError, "\n")
+	}
+
+	l := goblex.NewLexer("recover", "abc!def\nghi", lexFun)
+
+	assert.Equal(suite.T(), goblex.TokenTypeError, l.NextEmittedToken().Type())
+	assert.Equal(suite.T(), "ghi", l.NextEmittedToken().String())
+	assert.Equal(suite.T(), goblex.TokenTypeEOF, l.NextEmittedToken().Type())
+}
+
+func (suite *GoblexTestSuite) TestRecoveryProgress() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.IsEOF() {
+			return nil
+		}
+
+		if lexer.CaptureIdent() {
+			lexer.Emit(identTokenType)
+		}
+
+		// errors at the sync token itself must not loop forever
+		return lexer.Errorf("unexpected input")
+	}
+
+	l := goblex.NewLexer("recover", "a;;b", lexFun)
+	l.Recovery = &goblex.Recovery{SyncTokens: []string{";"}}
+
+	errCount := 0
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		if token.Type() == goblex.TokenTypeError {
+			errCount++
+		}
+	}
+
+	assert.Equal(suite.T(), 3, errCount)
+}
+
+func (suite *GoblexTestSuite) TestRecoveryAtEOF() {
+	suite.T().Parallel()
+
+	var lexFun goblex.LexFn
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if !lexer.CaptureIdent() {
+			return lexer.Errorf("expected ident")
+		}
+
+		lexer.Emit(identTokenType)
+		return lexFun
+	}
+
+	for _, syncTokens := range [][]string{nil, {";"}} {
+		l := goblex.NewLexer("recovery at eof", "ab $", lexFun)
+		l.AutoEatWhitespace = true
+		l.Recovery = &goblex.Recovery{SyncTokens: syncTokens}
+
+		var values []string
+		for token := range l.All() {
+			if token.Type() == goblex.TokenTypeError {
+				values = append(values, "ERROR "+token.(goblex.ErrorToken).Err().Error())
+				continue
+			}
+			values = append(values, token.String())
+		}
+
+		assert.Equal(suite.T(), []string{"ab", "ERROR 1:4: expected ident", "ERROR 1:5: expected ident", "EOF"}, values)
+	}
+}
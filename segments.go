@@ -0,0 +1,93 @@
+package goblex
+
+import "unicode/utf8"
+
+// Segment is a contiguous region of the input that contributed to the value of a token.
+//
+// The value of a token captured while skipping whitespace or ignore tokens, e.g. by CaptureUntil, is
+// stitched together from several regions of the input. Each of those regions becomes a Segment.
+type Segment struct {
+	// Start is the position of the first rune of the segment in the input
+	Start Position
+	// End is the position directly following the last rune of the segment in the input
+	End Position
+	// ValueStart is the byte offset in the token's value where the text of the segment starts
+	ValueStart int
+	// ValueEnd is the byte offset in the token's value directly following the text of the segment
+	ValueEnd int
+	// Decoded is true if the text of the segment was decoded from the input, e.g. an escape sequence
+	// in a quoted string, rather than copied verbatim
+	Decoded bool
+}
+
+// SegmentedToken is a Token that knows which regions of the input its value was captured from.
+//
+// All tokens emitted by the Emit method implement this interface. Custom tokens passed to EmitToken can
+// receive their segments by implementing SegmentReceiver.
+type SegmentedToken interface {
+	Token
+
+	// Segments returns the regions of the input the token's value was captured from in order
+	Segments() []Segment
+}
+
+// SegmentReceiver can be implemented by custom tokens passed to EmitToken to receive the segments of the
+// capture buffer their value was built from, or if it is empty the segments of the capture buffer last
+// cleared by Flush.
+type SegmentReceiver interface {
+	// WithSegments returns a copy of the token with the given segments set.
+	WithSegments(segments []Segment) Token
+}
+
+// ValuePosition returns the position in the input of the rune found at the byte offset in the value of
+// token and whether offset is within the value. Every rune of a decoded segment is positioned at the
+// start of the segment.
+func ValuePosition(token SegmentedToken, offset int) (Position, bool) {
+	value := token.String()
+	for _, seg := range token.Segments() {
+		if offset < seg.ValueStart || offset >= seg.ValueEnd {
+			continue
+		}
+
+		pos := seg.Start
+		if seg.Decoded {
+			return pos, true
+		}
+
+		for _, ch := range value[seg.ValueStart:offset] {
+			pos.Offset += utf8.RuneLen(ch)
+			if ch == '\n' {
+				pos.Line++
+				pos.Column = 1
+			} else {
+				pos.Column++
+			}
+		}
+
+		return pos, true
+	}
+
+	return Position{}, false
+}
+
+// addSegment records that the input from start to end was captured as value bytes written to the
+// capture buffer, extending the last segment if it directly precedes start.
+func (lxr *Lexer) addSegment(start, end Position, valueLen int, decoded bool) {
+	valueStart := lxr.tokenBuffer.Len() - valueLen
+	if n := len(lxr.segments); n > 0 && !decoded {
+		last := &lxr.segments[n-1]
+		if !last.Decoded && last.End == start && last.ValueEnd == valueStart {
+			last.End = end
+			last.ValueEnd = valueStart + valueLen
+			return
+		}
+	}
+
+	lxr.segments = append(lxr.segments, Segment{
+		Start:      start,
+		End:        end,
+		ValueStart: valueStart,
+		ValueEnd:   valueStart + valueLen,
+		Decoded:    decoded,
+	})
+}
@@ -0,0 +1,149 @@
+package goblex_test
+
+import (
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *GoblexTestSuite) lexSegmented(input string, lexFun goblex.LexFn) goblex.SegmentedToken {
+	l := goblex.NewLexer("segments", input, lexFun)
+	l.AddIgnoreRange("/*", "*/", false)
+
+	return l.NextEmittedToken().(goblex.SegmentedToken)
+}
+
+func (suite *GoblexTestSuite) TestSegmentsCaptureUntil() {
+	suite.T().Parallel()
+
+	token := suite.lexSegmented("some /* c */ te\nxt]", func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureUntil(true, "]")
+		lexer.Emit(basicTokenType)
+		return nil
+	})
+
+	assert.Equal(suite.T(), "sometext", token.String())
+	assert.Equal(suite.T(), []goblex.Segment{
+		{
+			Start:      goblex.Position{Offset: 0, Line: 1, Column: 1},
+			End:        goblex.Position{Offset: 4, Line: 1, Column: 5},
+			ValueStart: 0,
+			ValueEnd:   4,
+		},
+		{
+			Start:      goblex.Position{Offset: 13, Line: 1, Column: 14},
+			End:        goblex.Position{Offset: 15, Line: 1, Column: 16},
+			ValueStart: 4,
+			ValueEnd:   6,
+		},
+		{
+			Start:      goblex.Position{Offset: 16, Line: 2, Column: 1},
+			End:        goblex.Position{Offset: 18, Line: 2, Column: 3},
+			ValueStart: 6,
+			ValueEnd:   8,
+		},
+	}, token.Segments())
+
+	pos, ok := goblex.ValuePosition(token, 5)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), "1:15", pos.String())
+
+	pos, ok = goblex.ValuePosition(token, 7)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), "2:2", pos.String())
+	assert.Equal(suite.T(), 17, pos.Offset)
+
+	_, ok = goblex.ValuePosition(token, 8)
+	assert.False(suite.T(), ok)
+}
+
+func (suite *GoblexTestSuite) TestSegmentsDecoded() {
+	suite.T().Parallel()
+
+	token := suite.lexSegmented(`"é\tb"`, func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureQuoted(`"`, goblex.EscapeGo, true)
+		lexer.Emit(basicTokenType)
+		return nil
+	})
+
+	assert.Equal(suite.T(), "é\tb", token.String())
+
+	segments := token.Segments()
+	assert.Len(suite.T(), segments, 3)
+	assert.False(suite.T(), segments[0].Decoded)
+	assert.True(suite.T(), segments[1].Decoded)
+	assert.Equal(suite.T(), 2, segments[1].ValueStart)
+	assert.Equal(suite.T(), 3, segments[1].ValueEnd)
+	assert.Equal(suite.T(), 3, segments[1].Start.Offset)
+	assert.Equal(suite.T(), 5, segments[1].End.Offset)
+	assert.False(suite.T(), segments[2].Decoded)
+
+	pos, ok := goblex.ValuePosition(token, 3)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), "1:5", pos.String())
+}
+
+func (suite *GoblexTestSuite) TestSegmentsRewind() {
+	suite.T().Parallel()
+
+	token := suite.lexSegmented("ab cd", func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureIdent()
+		m := lexer.Mark()
+		lexer.CaptureIdent()
+		lexer.Rewind(m)
+		lexer.Emit(basicTokenType)
+		return nil
+	})
+
+	assert.Equal(suite.T(), "ab", token.String())
+	assert.Len(suite.T(), token.Segments(), 1)
+	assert.Equal(suite.T(), 2, token.Segments()[0].End.Offset)
+}
+
+type segmentSliceToken struct {
+	value    string
+	segments []goblex.Segment
+}
+
+func (t segmentSliceToken) Type() goblex.TokenType {
+	return basicTokenType
+}
+
+func (t segmentSliceToken) String() string {
+	return t.value
+}
+
+func (t segmentSliceToken) Segments() []goblex.Segment {
+	return t.segments
+}
+
+func (t segmentSliceToken) WithSegments(segments []goblex.Segment) goblex.Token {
+	t.segments = segments
+	return t
+}
+
+func (suite *GoblexTestSuite) TestSegmentsEmitToken() {
+	suite.T().Parallel()
+
+	token := suite.lexSegmented("ab /* c */ d]", func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureUntil(true, "]")
+		lexer.EmitToken(segmentSliceToken{value: lexer.Flush()})
+		return nil
+	})
+
+	assert.Equal(suite.T(), "abd", token.String())
+	assert.Equal(suite.T(), []goblex.Segment{
+		{
+			Start:      goblex.Position{Offset: 0, Line: 1, Column: 1},
+			End:        goblex.Position{Offset: 2, Line: 1, Column: 3},
+			ValueStart: 0,
+			ValueEnd:   2,
+		},
+		{
+			Start:      goblex.Position{Offset: 11, Line: 1, Column: 12},
+			End:        goblex.Position{Offset: 12, Line: 1, Column: 13},
+			ValueStart: 2,
+			ValueEnd:   3,
+		},
+	}, token.Segments())
+}
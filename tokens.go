@@ -1,6 +1,11 @@
 package goblex
 
-// RuneEOF is a rune representing the end of the input
+import "fmt"
+
+// RuneEOF is the rune the lexer reports as the current rune once the end of the input is reached.
+//
+// Since the input itself may contain U+0000, the end of the input is tracked separately and should be
+// tested with IsEOF rather than by comparing runes to RuneEOF.
 const RuneEOF rune = 0
 
 const (
@@ -15,15 +20,15 @@ var (
 )
 
 // TokenType is the type used by the Emit method to emit tokens. Implementors should create
-// their own types to emit when building a lexer.
-type TokenType int8
+// their own types to emit when building a lexer, either as constants or with RegisterTokenType.
+type TokenType int32
 
 const (
 	// TokenTypeError is a TokenType that can be used to emit errors
 	TokenTypeError TokenType = -2
 
 	// TokenTypeEOF is a TokenType that can be used to emit the end of the imput
-	TokenTypeEOF = -1
+	TokenTypeEOF TokenType = -1
 )
 
 // Token is the type that gets emitted by the Emit method.
@@ -36,9 +41,49 @@ type Token interface {
 	String() string
 }
 
+// Position is a location in the input of a lexer.
+type Position struct {
+	// Offset is the byte offset from the start of the input, starting at 0
+	Offset int
+	// Line is the line number, starting at 1
+	Line int
+	// Column is the rune offset from the start of the line, starting at 1
+	Column int
+}
+
+// String returns the position formatted as line:column
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// PositionedToken is a Token that knows where it was found in the input.
+//
+// All tokens emitted by the Emit and Errorf methods implement this interface.
+type PositionedToken interface {
+	Token
+
+	// Start returns the position of the first rune of the token
+	Start() Position
+
+	// End returns the position directly following the last rune of the token
+	End() Position
+}
+
+// Positioner can be implemented by custom tokens passed to EmitToken to receive the positions the
+// lexer recorded for them.
+type Positioner interface {
+	// WithPosition returns a copy of the token with the given start and end positions set.
+	WithPosition(start, end Position) Token
+}
+
 type defaultToken struct {
 	tokenType TokenType
 	value     string
+	start     Position
+	end       Position
+	trivia    trivia
+	segments  []Segment
+	err       *LexError
 }
 
 func (t defaultToken) Type() TokenType {
@@ -48,3 +93,35 @@ func (t defaultToken) Type() TokenType {
 func (t defaultToken) String() string {
 	return t.value
 }
+
+func (t defaultToken) Start() Position {
+	return t.start
+}
+
+func (t defaultToken) End() Position {
+	return t.end
+}
+
+func (t defaultToken) LeadingTrivia() string {
+	return t.trivia.leading
+}
+
+func (t defaultToken) Raw() string {
+	return t.trivia.raw
+}
+
+func (t defaultToken) TrailingTrivia() string {
+	return t.trivia.trailing
+}
+
+func (t defaultToken) Segments() []Segment {
+	return t.segments
+}
+
+func (t defaultToken) Err() error {
+	if t.err == nil {
+		return nil
+	}
+
+	return t.err
+}
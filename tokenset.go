@@ -0,0 +1,40 @@
+package goblex
+
+// TokenSet is a reusable set of tokens compiled into a prefix tree so the lexer can find the longest
+// token of the set at the current position in a single pass.
+//
+// This is useful for operator-heavy grammars where tokens share prefixes, e.g. "<", "<=" and "<<=",
+// since the longest token is always found regardless of the order the tokens were added in.
+type TokenSet struct {
+	keys trie
+	all  map[string]bool
+}
+
+// NewTokenSet creates a new TokenSet containing tokens. Blank tokens are ignored.
+func NewTokenSet(tokens ...string) *TokenSet {
+	set := &TokenSet{}
+	set.Add(tokens...)
+
+	return set
+}
+
+// Add adds tokens to the set. Blank tokens are ignored.
+func (s *TokenSet) Add(tokens ...string) {
+	for _, tkn := range tokens {
+		if tkn == "" {
+			continue
+		}
+
+		if s.all == nil {
+			s.all = make(map[string]bool)
+		}
+
+		s.keys.insert(tkn)
+		s.all[tkn] = true
+	}
+}
+
+// Contains returns whether token is in the set.
+func (s *TokenSet) Contains(token string) bool {
+	return s.all[token]
+}
@@ -0,0 +1,118 @@
+package goblex_test
+
+import (
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *GoblexTestSuite) lexOperators(input string, setup func(l *goblex.Lexer), match func(l *goblex.Lexer) string) []string {
+	var values []string
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if match(lexer) == "" {
+			return nil
+		}
+
+		lexer.Emit(basicTokenType)
+		lexer.ConsumeCurrentToken(true)
+		lexer.Emit(basicTokenType)
+		return lexFun
+	}
+
+	l := goblex.NewLexer("simple", input, lexFun)
+	setup(l)
+	for {
+		token := l.NextEmittedToken()
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+
+		values = append(values, token.String())
+	}
+
+	return values
+}
+
+func (suite *GoblexTestSuite) TestCaptureUntilOneOfArgumentOrder() {
+	suite.T().Parallel()
+
+	match := func(l *goblex.Lexer) string {
+		return l.CaptureUntilOneOf(true, "=", "<", "==", "<=")
+	}
+
+	first := suite.lexOperators("a == b <= c", func(l *goblex.Lexer) {}, match)
+	longest := suite.lexOperators("a == b <= c", func(l *goblex.Lexer) { l.LongestMatch = true }, match)
+
+	assert.Equal(suite.T(), []string{"a", "=", "", "=", "b", "<", "", "="}, first)
+	assert.Equal(suite.T(), []string{"a", "==", "b", "<="}, longest)
+}
+
+func (suite *GoblexTestSuite) TestCurrentTokenIsOneOfLongestMatch() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", "<<=", lexFun)
+
+	_, tkn := l.CurrentTokenIsOneOf("<", "<<", "<<=", "<=")
+	assert.Equal(suite.T(), "<", tkn)
+
+	l.LongestMatch = true
+	_, tkn = l.CurrentTokenIsOneOf("<", "<<", "<<=", "<=")
+	assert.Equal(suite.T(), "<<=", tkn)
+	_, tkn = l.CurrentTokenIsOneOf("<", "<<", "<=")
+	assert.Equal(suite.T(), "<<", tkn)
+}
+
+func (suite *GoblexTestSuite) TestCaptureUntilIn() {
+	suite.T().Parallel()
+
+	ops := goblex.NewTokenSet("=", "<", "==", "<=", "<<=", "!=")
+	match := func(l *goblex.Lexer) string {
+		return l.CaptureUntilIn(true, ops)
+	}
+
+	values := suite.lexOperators("a == b <= c <<= d != e = f", func(l *goblex.Lexer) {}, match)
+
+	assert.Equal(suite.T(), []string{"a", "==", "b", "<=", "c", "<<=", "d", "!=", "e", "="}, values)
+}
+
+func (suite *GoblexTestSuite) TestCurrentTokenIsIn() {
+	suite.T().Parallel()
+
+	ops := goblex.NewTokenSet(">", ">>", ">=")
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		return nil
+	}
+
+	l := goblex.NewLexer("simple", ">>= x", lexFun)
+
+	found, tkn := l.CurrentTokenIsIn(ops)
+	assert.True(suite.T(), found)
+	assert.Equal(suite.T(), ">>", tkn)
+	assert.True(suite.T(), l.ConsumeCurrentToken(true))
+	assert.Equal(suite.T(), ">>", l.Flush())
+
+	found, tkn = l.CurrentTokenIsIn(ops)
+	assert.False(suite.T(), found)
+	assert.Equal(suite.T(), "", tkn)
+
+	found, _ = l.CurrentTokenIsIn(nil)
+	assert.False(suite.T(), found)
+}
+
+func (suite *GoblexTestSuite) TestTokenSetContains() {
+	suite.T().Parallel()
+
+	var ops goblex.TokenSet
+	ops.Add("+", "", "++")
+
+	assert.True(suite.T(), ops.Contains("+"))
+	assert.True(suite.T(), ops.Contains("++"))
+	assert.False(suite.T(), ops.Contains(""))
+	assert.False(suite.T(), ops.Contains("+++"))
+}
@@ -0,0 +1,202 @@
+package goblex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// firstRegisteredTokenType is the first TokenType handed out by RegisterTokenType. It leaves room for the
+// TokenType constants implementors declare themselves.
+const firstRegisteredTokenType TokenType = 1 << 16
+
+// Category classifies registered TokenTypes so parsers and highlighters can treat whole groups of types
+// alike without per-language tables.
+type Category uint8
+
+const (
+	// CategoryNone is the Category of TokenTypes registered without one and of unregistered types
+	CategoryNone Category = iota
+	// CategoryKeyword is the Category of reserved words, e.g. SELECT or func
+	CategoryKeyword
+	// CategoryOperator is the Category of operators, e.g. + or <=
+	CategoryOperator
+	// CategoryLiteral is the Category of literal values, e.g. numbers and quoted strings
+	CategoryLiteral
+	// CategoryComment is the Category of comments
+	CategoryComment
+	// CategoryPunctuation is the Category of punctuation, e.g. parentheses, commas and semicolons
+	CategoryPunctuation
+)
+
+var categoryNames = [...]string{
+	CategoryNone:        "none",
+	CategoryKeyword:     "keyword",
+	CategoryOperator:    "operator",
+	CategoryLiteral:     "literal",
+	CategoryComment:     "comment",
+	CategoryPunctuation: "punctuation",
+}
+
+// String returns the lower case name of the Category.
+func (c Category) String() string {
+	if int(c) < len(categoryNames) {
+		return categoryNames[c]
+	}
+
+	return fmt.Sprintf("Category(%d)", uint8(c))
+}
+
+var tokenTypes = struct {
+	sync.RWMutex
+	next       TokenType
+	names      map[TokenType]string
+	byName     map[string]TokenType
+	categories map[TokenType]Category
+}{
+	next: firstRegisteredTokenType,
+	names: map[TokenType]string{
+		TokenTypeError: "ERROR",
+		TokenTypeEOF:   "EOF",
+	},
+	byName: map[string]TokenType{
+		"ERROR": TokenTypeError,
+		"EOF":   TokenTypeEOF,
+	},
+	categories: map[TokenType]Category{},
+}
+
+// RegisterTokenType allocates a new TokenType named name. This is an alternative to declaring TokenType
+// constants that gives the type a name for String, debug logs and serialized output, e.g.
+//
+//	var SelectTokenType = goblex.RegisterTokenType("SELECT")
+//
+// Registered types start at 65536 so they never collide with TokenType constants below that value.
+//
+// RegisterTokenType is safe for concurrent use. It panics if name is blank or already registered, which
+// includes the built-in names "ERROR" and "EOF", so types are best registered in package level variables.
+func RegisterTokenType(name string) TokenType {
+	return RegisterTokenTypeInCategory(name, CategoryNone)
+}
+
+// RegisterTokenTypeInCategory does the same thing as RegisterTokenType and records category as the
+// Category of the new TokenType, e.g.
+//
+//	var NumberTokenType = goblex.RegisterTokenTypeInCategory("NUMBER", goblex.CategoryLiteral)
+func RegisterTokenTypeInCategory(name string, category Category) TokenType {
+	if name == "" {
+		panic("goblex: RegisterTokenType called with a blank name")
+	}
+
+	tokenTypes.Lock()
+	defer tokenTypes.Unlock()
+
+	if _, dup := tokenTypes.byName[name]; dup {
+		panic(fmt.Sprintf("goblex: RegisterTokenType called twice for %q", name))
+	}
+
+	tokenType := tokenTypes.next
+	tokenTypes.next++
+	tokenTypes.names[tokenType] = name
+	tokenTypes.byName[name] = tokenType
+	if category != CategoryNone {
+		tokenTypes.categories[tokenType] = category
+	}
+
+	return tokenType
+}
+
+// TokenTypesIn returns a new TokenTypeSet of the TokenTypes registered so far in any of categories.
+func TokenTypesIn(categories ...Category) *TokenTypeSet {
+	set := NewTokenTypeSet()
+
+	tokenTypes.RLock()
+	defer tokenTypes.RUnlock()
+
+	for tokenType, category := range tokenTypes.categories {
+		for _, c := range categories {
+			if c == category {
+				set.Add(tokenType)
+				break
+			}
+		}
+	}
+
+	return set
+}
+
+// LookupTokenType returns the TokenType registered as name and whether it was found. The built-in types
+// are registered as "ERROR" and "EOF".
+func LookupTokenType(name string) (TokenType, bool) {
+	tokenTypes.RLock()
+	defer tokenTypes.RUnlock()
+
+	tokenType, found := tokenTypes.byName[name]
+	return tokenType, found
+}
+
+// String returns the name the TokenType was registered with or TokenType(n) if it has none.
+func (t TokenType) String() string {
+	tokenTypes.RLock()
+	name, found := tokenTypes.names[t]
+	tokenTypes.RUnlock()
+
+	if !found {
+		return fmt.Sprintf("TokenType(%d)", int32(t))
+	}
+
+	return name
+}
+
+// Category returns the Category the TokenType was registered in or CategoryNone if it has none.
+func (t TokenType) Category() Category {
+	tokenTypes.RLock()
+	defer tokenTypes.RUnlock()
+
+	return tokenTypes.categories[t]
+}
+
+// MarshalText implements encoding.TextMarshaler using String, so TokenTypes are written to JSON as strings
+// such as "ERROR" or "TokenType(1)" rather than as numbers.
+func (t TokenType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler accepting registered names as well as the TokenType(n)
+// form String returns for unnamed types.
+func (t *TokenType) UnmarshalText(text []byte) error {
+	if tokenType, found := LookupTokenType(string(text)); found {
+		*t = tokenType
+		return nil
+	}
+
+	var n int32
+	if _, err := fmt.Sscanf(string(text), "TokenType(%d)", &n); err != nil {
+		return fmt.Errorf("unknown token type %q", text)
+	}
+
+	*t = TokenType(n)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler accepting the strings written by MarshalText as well as plain
+// numbers, so JSON written before TokenTypes were encoded by name can still be read.
+func (t *TokenType) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var n int32
+	if err := json.Unmarshal(data, &n); err == nil {
+		*t = TokenType(n)
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("token type must be a JSON string or number, got %s", data)
+	}
+
+	return t.UnmarshalText([]byte(text))
+}
@@ -0,0 +1,138 @@
+package goblex_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// token types are registered once per test binary since registering a name twice panics
+var (
+	registryTableType  = goblex.RegisterTokenType("registry.TABLE")
+	registryColumnType = goblex.RegisterTokenType("registry.COLUMN")
+	registryStringType = goblex.RegisterTokenType("registry.STRING")
+	registryTwiceType  = goblex.RegisterTokenType("registry.TWICE")
+
+	categoryNumberType = goblex.RegisterTokenTypeInCategory("category.NUMBER", goblex.CategoryLiteral)
+	categoryStringType = goblex.RegisterTokenTypeInCategory("category.STRING", goblex.CategoryLiteral)
+	categoryPlusType   = goblex.RegisterTokenTypeInCategory("category.PLUS", goblex.CategoryOperator)
+	categoryNameType   = goblex.RegisterTokenType("category.NAME")
+
+	concurrentRegistryRuns int32
+)
+
+func (suite *GoblexTestSuite) TestBuiltinTokenTypeNames() {
+	suite.T().Parallel()
+
+	assert.Equal(suite.T(), "ERROR", goblex.TokenTypeError.String())
+	assert.Equal(suite.T(), "EOF", goblex.TokenTypeEOF.String())
+	assert.Equal(suite.T(), "EOF", fmt.Sprint(goblex.TokenTypeEOF))
+	assert.Equal(suite.T(), "TokenType(20)", indentTokenType.String())
+	assert.Equal(suite.T(), "EOF", fmt.Sprint(goblex.NewLexer("names", "", nil).NextEmittedToken().Type()))
+}
+
+func (suite *GoblexTestSuite) TestRegisterTokenType() {
+	suite.T().Parallel()
+
+	assert.NotEqual(suite.T(), registryTableType, registryColumnType)
+	assert.Greater(suite.T(), int32(registryTableType), int32(127))
+	assert.Equal(suite.T(), "registry.TABLE", registryTableType.String())
+	assert.Equal(suite.T(), "registry.COLUMN", fmt.Sprintf("%v", registryColumnType))
+
+	found, ok := goblex.LookupTokenType("registry.TABLE")
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), registryTableType, found)
+
+	_, ok = goblex.LookupTokenType("registry.MISSING")
+	assert.False(suite.T(), ok)
+}
+
+func (suite *GoblexTestSuite) TestRegisterTokenTypeTwice() {
+	suite.T().Parallel()
+
+	assert.Panics(suite.T(), func() { goblex.RegisterTokenType("registry.TWICE") })
+	assert.Panics(suite.T(), func() { goblex.RegisterTokenTypeInCategory("registry.TWICE", goblex.CategoryKeyword) })
+	assert.Equal(suite.T(), goblex.CategoryNone, registryTwiceType.Category())
+	assert.Panics(suite.T(), func() { goblex.RegisterTokenType("ERROR") })
+	assert.Panics(suite.T(), func() { goblex.RegisterTokenType("EOF") })
+	assert.Panics(suite.T(), func() { goblex.RegisterTokenType("") })
+}
+
+func (suite *GoblexTestSuite) TestRegisterTokenTypeConcurrently() {
+	suite.T().Parallel()
+
+	// names are unique per run so the test can be repeated with -count
+	run := atomic.AddInt32(&concurrentRegistryRuns, 1)
+
+	var wg sync.WaitGroup
+	types := make([]goblex.TokenType, 50)
+	for i := range types {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			types[i] = goblex.RegisterTokenType(fmt.Sprintf("registry.CONCURRENT%d.%d", run, i))
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[goblex.TokenType]bool)
+	for i, tokenType := range types {
+		assert.False(suite.T(), seen[tokenType])
+		assert.Equal(suite.T(), fmt.Sprintf("registry.CONCURRENT%d.%d", run, i), tokenType.String())
+		seen[tokenType] = true
+	}
+}
+
+func (suite *GoblexTestSuite) TestTokenTypeJSON() {
+	suite.T().Parallel()
+
+	types := []goblex.TokenType{registryStringType, goblex.TokenTypeError, 300}
+
+	data, err := json.Marshal(types)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), `["registry.STRING","ERROR","TokenType(300)"]`, string(data))
+
+	var decoded []goblex.TokenType
+	assert.NoError(suite.T(), json.Unmarshal(data, &decoded))
+	assert.Equal(suite.T(), types, decoded)
+
+	assert.Error(suite.T(), json.Unmarshal([]byte(`["registry.UNKNOWN"]`), &decoded))
+
+	// numbers written before TokenTypes were encoded by name are still accepted
+	assert.NoError(suite.T(), json.Unmarshal([]byte(`[300, -2, "EOF"]`), &decoded))
+	assert.Equal(suite.T(), []goblex.TokenType{300, goblex.TokenTypeError, goblex.TokenTypeEOF}, decoded)
+	assert.Error(suite.T(), json.Unmarshal([]byte(`[true]`), &decoded))
+}
+
+func (suite *GoblexTestSuite) TestTokenTypeCategories() {
+	suite.T().Parallel()
+
+	assert.Equal(suite.T(), goblex.CategoryLiteral, categoryNumberType.Category())
+	assert.Equal(suite.T(), goblex.CategoryOperator, categoryPlusType.Category())
+	assert.Equal(suite.T(), goblex.CategoryNone, categoryNameType.Category())
+	assert.Equal(suite.T(), goblex.CategoryNone, basicTokenType.Category())
+	assert.Equal(suite.T(), goblex.CategoryNone, goblex.TokenTypeError.Category())
+
+	literals := goblex.TokenTypesIn(goblex.CategoryLiteral)
+	assert.True(suite.T(), literals.Contains(categoryNumberType))
+	assert.True(suite.T(), literals.Contains(categoryStringType))
+	assert.False(suite.T(), literals.Contains(categoryPlusType))
+	assert.False(suite.T(), literals.Contains(categoryNameType))
+
+	operands := goblex.TokenTypesIn(goblex.CategoryLiteral, goblex.CategoryOperator)
+	assert.True(suite.T(), operands.Contains(categoryPlusType))
+	assert.True(suite.T(), operands.Contains(categoryStringType))
+}
+
+func (suite *GoblexTestSuite) TestCategoryString() {
+	suite.T().Parallel()
+
+	assert.Equal(suite.T(), "keyword", goblex.CategoryKeyword.String())
+	assert.Equal(suite.T(), "punctuation", fmt.Sprint(goblex.CategoryPunctuation))
+	assert.Equal(suite.T(), "Category(42)", goblex.Category(42).String())
+}
@@ -0,0 +1,115 @@
+package goblex
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// trie is a prefix tree of strings keyed by rune used to find the longest of a set of tokens at the
+// current position in a single pass instead of testing every token in turn.
+type trie struct {
+	root   trieNode
+	maxLen int
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	terminal bool
+	key      string
+}
+
+func (t *trie) insert(key string) {
+	if key == "" {
+		return
+	}
+
+	node := &t.root
+	for _, r := range key {
+		if node.children == nil {
+			node.children = make(map[rune]*trieNode)
+		}
+
+		next, ok := node.children[r]
+		if !ok {
+			next = &trieNode{}
+			node.children[r] = next
+		}
+		node = next
+	}
+
+	node.terminal = true
+	node.key = key
+
+	if n := utf8.RuneCountInString(key); n > t.maxLen {
+		t.maxLen = n
+	}
+}
+
+func (t *trie) remove(key string) {
+	node := &t.root
+	for _, r := range key {
+		node = node.children[r]
+		if node == nil {
+			return
+		}
+	}
+
+	node.terminal = false
+	node.key = ""
+}
+
+func (t *trie) empty() bool {
+	return len(t.root.children) == 0
+}
+
+// longestMatch returns the longest key that is a prefix of runes or "" if no key matches.
+func (t *trie) longestMatch(runes []rune) string {
+	longest := ""
+	node := &t.root
+	for _, r := range runes {
+		node = node.children[r]
+		if node == nil {
+			break
+		}
+
+		if node.terminal {
+			longest = node.key
+		}
+	}
+
+	return longest
+}
+
+// longestFoldMatch does the same thing as longestMatch but compares runes using Unicode simple case
+// folding. Keys that match runes exactly are preferred over keys of the same length that only match
+// when folded.
+func (t *trie) longestFoldMatch(runes []rune) string {
+	return foldMatch(&t.root, runes)
+}
+
+func foldMatch(node *trieNode, runes []rune) string {
+	if len(runes) == 0 {
+		return ""
+	}
+
+	longest := ""
+	r := runes[0]
+	for ch := r; ; {
+		if next := node.children[ch]; next != nil {
+			found := foldMatch(next, runes[1:])
+			if found == "" && next.terminal {
+				found = next.key
+			}
+
+			if utf8.RuneCountInString(found) > utf8.RuneCountInString(longest) {
+				longest = found
+			}
+		}
+
+		if ch = unicode.SimpleFold(ch); ch == r {
+			break
+		}
+	}
+
+	return longest
+}
@@ -0,0 +1,186 @@
+package goblex
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// TriviaToken is a Token that carries the input that was skipped around it when the lexer's
+// PreserveTrivia flag is set, so that concatenating LeadingTrivia, Raw and TrailingTrivia of every
+// emitted token, including the EOF token, reproduces the input byte for byte.
+//
+// All tokens emitted by the Emit method, error tokens and the EOF token implement this interface. Error
+// tokens only carry leading trivia. The trivia of all tokens is empty if PreserveTrivia is not set.
+type TriviaToken interface {
+	Token
+
+	// LeadingTrivia returns the whitespace, ignored text and discarded captures that were skipped
+	// between the previous token and this one
+	LeadingTrivia() string
+
+	// Raw returns the token as it appeared in the input, which differs from String if the captured
+	// value was transformed, e.g. by decoding the escape sequences of a quoted string
+	Raw() string
+
+	// TrailingTrivia returns the input skipped after this token before it was emitted, up to and
+	// including the first newline
+	TrailingTrivia() string
+}
+
+// trivia is the input found around a token.
+type trivia struct {
+	leading  string
+	raw      string
+	trailing string
+}
+
+// TriviaReceiver can be implemented by custom tokens passed to EmitToken to receive their trivia when
+// PreserveTrivia is set. Trivia of custom tokens that do not implement it becomes part of the leading
+// trivia of the next token instead.
+type TriviaReceiver interface {
+	// WithTrivia returns a copy of the token with the given trivia set.
+	WithTrivia(leading, raw, trailing string) Token
+}
+
+// appendRaw appends the bytes of the input r was decoded from to b.
+func (r cachedRune) appendRaw(b []byte) []byte {
+	if r.ch == utf8.RuneError && r.size == 1 {
+		return append(b, r.raw)
+	}
+
+	return utf8.AppendRune(b, r.ch)
+}
+
+// recordRune records the current rune as it is consumed if PreserveTrivia is set. Input consumed while
+// PreserveTrivia is not set is never recorded and stops recording for good.
+func (lxr *Lexer) recordRune() {
+	if !lxr.recordTrivia || lxr.eof {
+		return
+	}
+
+	if !lxr.PreserveTrivia {
+		lxr.recordTrivia = false
+		lxr.rawInput = nil
+		return
+	}
+
+	// runes read again after a Rewind have already been recorded
+	if lxr.currentPos.Offset == lxr.rawBase+len(lxr.rawInput) {
+		lxr.rawInput = lxr.current().appendRaw(lxr.rawInput)
+	}
+}
+
+// recordRest records the input that was not consumed before lexing ended so it becomes the leading
+// trivia of the EOF token.
+func (lxr *Lexer) recordRest() {
+	if !lxr.PreserveTrivia || !lxr.recordTrivia {
+		return
+	}
+
+	var rest []byte
+	if !lxr.eof {
+		rest = lxr.current().appendRaw(rest)
+	}
+	for _, cr := range lxr.runeCache {
+		rest = cr.appendRaw(rest)
+	}
+
+	if recorded := lxr.rawBase + len(lxr.rawInput) - lxr.currentPos.Offset; recorded < len(rest) {
+		lxr.rawInput = append(lxr.rawInput, rest[recorded:]...)
+	}
+
+	if lxr.inputErr == nil {
+		unread, err := io.ReadAll(lxr.inputBuffer)
+		lxr.rawInput = append(lxr.rawInput, unread...)
+		if err != nil {
+			lxr.inputErr = err
+		}
+	}
+}
+
+// takeTrivia returns the trivia of a token spanning from start to end and starts the leading trivia of
+// the next token.
+func (lxr *Lexer) takeTrivia(start, end Position) trivia {
+	if !lxr.PreserveTrivia || !lxr.recordTrivia {
+		return trivia{}
+	}
+
+	// the start of a token captured before an earlier token was emitted may already be trivia
+	if start.Offset < lxr.triviaStart {
+		start.Offset = lxr.triviaStart
+	}
+	if end.Offset < start.Offset {
+		end.Offset = start.Offset
+	}
+
+	consumed := lxr.rawBytes(lxr.triviaStart, lxr.currentPos.Offset)
+	afterToken := end.Offset - lxr.triviaStart
+	trailingEnd := len(consumed)
+	if i := bytes.IndexByte(consumed[afterToken:], '\n'); i >= 0 {
+		trailingEnd = afterToken + i + 1
+	}
+
+	t := trivia{
+		leading:  string(consumed[:start.Offset-lxr.triviaStart]),
+		raw:      string(consumed[start.Offset-lxr.triviaStart : afterToken]),
+		trailing: string(consumed[afterToken:trailingEnd]),
+	}
+
+	lxr.triviaStart += trailingEnd
+	lxr.trimTrivia()
+	return t
+}
+
+// takeErrorTrivia returns the input skipped before an error found at pos as the leading trivia of the
+// error token. Input already written to the capture buffer, or flushed from it for a custom token, is
+// left to the token it is emitted with.
+func (lxr *Lexer) takeErrorTrivia(pos Position) trivia {
+	if !lxr.PreserveTrivia || !lxr.recordTrivia {
+		return trivia{}
+	}
+
+	end := pos.Offset
+	if span, ok := lxr.emitSpan(); ok && span.start.Offset < end {
+		end = span.start.Offset
+	}
+	if recorded := lxr.rawBase + len(lxr.rawInput); end > recorded {
+		end = recorded
+	}
+	if end <= lxr.triviaStart {
+		return trivia{}
+	}
+
+	t := trivia{leading: string(lxr.rawBytes(lxr.triviaStart, end))}
+	lxr.triviaStart = end
+	lxr.trimTrivia()
+	return t
+}
+
+// takeEOFTrivia returns the rest of the input as the leading trivia of the EOF token.
+func (lxr *Lexer) takeEOFTrivia() trivia {
+	if !lxr.PreserveTrivia || !lxr.recordTrivia {
+		return trivia{}
+	}
+
+	t := trivia{leading: string(lxr.rawBytes(lxr.triviaStart, lxr.rawBase+len(lxr.rawInput)))}
+	lxr.triviaStart = lxr.rawBase + len(lxr.rawInput)
+	lxr.trimTrivia()
+	return t
+}
+
+// rawBytes returns the recorded input from offset start up to offset end.
+func (lxr *Lexer) rawBytes(start, end int) []byte {
+	return lxr.rawInput[start-lxr.rawBase : end-lxr.rawBase]
+}
+
+// trimTrivia discards recorded input that can no longer be part of any trivia. Input is kept while
+// marks are active since it may be read again after a Rewind.
+func (lxr *Lexer) trimTrivia() {
+	if lxr.markDepth > 0 {
+		return
+	}
+
+	lxr.rawInput = lxr.rawInput[lxr.triviaStart-lxr.rawBase:]
+	lxr.rawBase = lxr.triviaStart
+}
@@ -0,0 +1,240 @@
+package goblex_test
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *GoblexTestSuite) lexTrivia(input string, preserve bool) []goblex.TriviaToken {
+	var lexFun goblex.LexFn
+
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.IsEOF() {
+			return nil
+		}
+
+		switch {
+		case lexer.CaptureIdent():
+		case lexer.CaptureQuoted(`"`, goblex.EscapeGo, true):
+		case lexer.CaptureBalanced("(", ")"):
+		default:
+			lexer.CaptureUntilFunc(func(rune) bool { return true })
+			lexer.ConsumeCurrentToken(true)
+		}
+
+		lexer.Emit(basicTokenType)
+		return lexFun
+	}
+
+	l := goblex.NewLexer("trivia", input, lexFun)
+	l.AddIgnoreRange("/*", "*/", false)
+	l.AddIgnoreToEOL("//")
+	l.PreserveTrivia = preserve
+
+	var tokens []goblex.TriviaToken
+	for {
+		token := l.NextEmittedToken()
+		tokens = append(tokens, token.(goblex.TriviaToken))
+		if token.Type() == goblex.TokenTypeEOF {
+			break
+		}
+	}
+
+	return tokens
+}
+
+func concatTrivia(tokens []goblex.TriviaToken) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteString(token.LeadingTrivia())
+		sb.WriteString(token.Raw())
+		sb.WriteString(token.TrailingTrivia())
+	}
+
+	return sb.String()
+}
+
+func (suite *GoblexTestSuite) TestPreserveTrivia() {
+	suite.T().Parallel()
+
+	input := "  a /* c */ b\n\t\"x\\ty\" // end\n+ c  \n\n"
+	tokens := suite.lexTrivia(input, true)
+
+	assert.Equal(suite.T(), input, concatTrivia(tokens))
+	assert.Len(suite.T(), tokens, 6)
+
+	assert.Equal(suite.T(), "  ", tokens[0].LeadingTrivia())
+	assert.Equal(suite.T(), "a", tokens[0].Raw())
+	assert.Equal(suite.T(), " /* c */ ", tokens[0].TrailingTrivia())
+
+	assert.Equal(suite.T(), "b", tokens[1].Raw())
+	assert.Equal(suite.T(), "\n", tokens[1].TrailingTrivia())
+
+	assert.Equal(suite.T(), "\t", tokens[2].LeadingTrivia())
+	assert.Equal(suite.T(), "x\ty", tokens[2].String())
+	assert.Equal(suite.T(), `"x\ty"`, tokens[2].Raw())
+	assert.Equal(suite.T(), " // end\n", tokens[2].TrailingTrivia())
+
+	assert.Equal(suite.T(), "+", tokens[3].Raw())
+	assert.Equal(suite.T(), "c", tokens[4].Raw())
+	assert.Equal(suite.T(), "  \n", tokens[4].TrailingTrivia())
+
+	assert.Equal(suite.T(), goblex.TokenTypeEOF, tokens[5].Type())
+	assert.Equal(suite.T(), "\n", tokens[5].LeadingTrivia())
+}
+
+func (suite *GoblexTestSuite) TestPreserveTriviaInvalidUTF8AndRewind() {
+	suite.T().Parallel()
+
+	// the unbalanced "(" is rewound and lexed again one rune at a time
+	input := "a\xff\xfe b (c \"d\" e"
+	tokens := suite.lexTrivia(input, true)
+
+	assert.Equal(suite.T(), input, concatTrivia(tokens))
+	assert.Equal(suite.T(), "\xff", tokens[1].Raw())
+	assert.Equal(suite.T(), goblex.TokenTypeError, tokens[4].Type())
+	assert.Equal(suite.T(), "(", tokens[5].Raw())
+}
+
+func (suite *GoblexTestSuite) TestPreserveTriviaDisabled() {
+	suite.T().Parallel()
+
+	tokens := suite.lexTrivia("  a /* c */ b\n", false)
+
+	assert.Len(suite.T(), tokens, 3)
+	for _, token := range tokens {
+		assert.Equal(suite.T(), "", token.LeadingTrivia())
+		assert.Equal(suite.T(), "", token.Raw())
+		assert.Equal(suite.T(), "", token.TrailingTrivia())
+	}
+	assert.Equal(suite.T(), "b", tokens[1].String())
+}
+
+func (suite *GoblexTestSuite) TestPreserveTriviaErrorTokens() {
+	suite.T().Parallel()
+
+	var lexFun goblex.LexFn
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.IsEOF() {
+			return nil
+		}
+
+		if !lexer.CaptureIdent() {
+			return lexer.Errorf("expected a word")
+		}
+
+		lexer.Emit(basicTokenType)
+		return lexFun
+	}
+
+	input := "a\n  ! b"
+	l := goblex.NewLexer("trivia", input, lexFun)
+	l.PreserveTrivia = true
+	l.Recovery = &goblex.Recovery{}
+
+	var tokens []goblex.TriviaToken
+	for token := range l.All() {
+		tokens = append(tokens, token.(goblex.TriviaToken))
+	}
+
+	assert.Equal(suite.T(), input, concatTrivia(tokens))
+	assert.Equal(suite.T(), "\n", tokens[0].TrailingTrivia())
+	assert.Equal(suite.T(), goblex.TokenTypeError, tokens[1].Type())
+	assert.Equal(suite.T(), "  ", tokens[1].LeadingTrivia())
+	assert.Equal(suite.T(), "! ", tokens[2].LeadingTrivia())
+	assert.Equal(suite.T(), "b", tokens[2].Raw())
+}
+
+func (suite *GoblexTestSuite) TestPreserveTriviaSetAfterLexing() {
+	suite.T().Parallel()
+
+	l := goblex.NewLexer("trivia", "  a b c", nil)
+	l.CaptureIdent()
+	l.Flush()
+
+	// input consumed without PreserveTrivia is never recorded, so recording stops for good
+	l.PreserveTrivia = true
+	l.CaptureIdent()
+	l.Emit(basicTokenType)
+
+	token := l.NextEmittedToken().(goblex.TriviaToken)
+	assert.Equal(suite.T(), "b", token.String())
+	assert.Equal(suite.T(), "", token.LeadingTrivia())
+	assert.Equal(suite.T(), "", token.Raw())
+}
+
+type triviaSliceToken struct {
+	value                  string
+	leading, raw, trailing string
+}
+
+func (t triviaSliceToken) Type() goblex.TokenType {
+	return basicTokenType
+}
+
+func (t triviaSliceToken) String() string {
+	return t.value
+}
+
+func (t triviaSliceToken) WithTrivia(leading, raw, trailing string) goblex.Token {
+	t.leading, t.raw, t.trailing = leading, raw, trailing
+	return t
+}
+
+func (suite *GoblexTestSuite) TestEmitTokenTrivia() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureIdent()
+		lexer.EmitToken(triviaSliceToken{value: lexer.Flush()})
+
+		lexer.CaptureIdent()
+		lexer.EmitToken(positionedSliceToken{value: lexer.Flush()})
+
+		lexer.CaptureIdent()
+		lexer.Emit(basicTokenType)
+		return nil
+	}
+
+	l := goblex.NewLexer("trivia", " one two three", lexFun)
+	l.PreserveTrivia = true
+
+	custom := l.NextEmittedToken().(triviaSliceToken)
+	assert.Equal(suite.T(), " ", custom.leading)
+	assert.Equal(suite.T(), "one", custom.raw)
+	assert.Equal(suite.T(), " ", custom.trailing)
+
+	// the trivia of tokens that cannot hold it goes to the next token
+	assert.Equal(suite.T(), "two", l.NextEmittedToken().String())
+	last := l.NextEmittedToken().(goblex.TriviaToken)
+	assert.Equal(suite.T(), "two ", last.LeadingTrivia())
+	assert.Equal(suite.T(), "three", last.Raw())
+}
+
+func (suite *GoblexTestSuite) TestEmitTokenTriviaAfterError() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureIdent()
+		value := lexer.Flush()
+		lexer.EmitError(errors.New("checked after flushing"))
+		lexer.EmitToken(triviaSliceToken{value: value})
+		return nil
+	}
+
+	l := goblex.NewLexer("trivia", "ab\n  cd", lexFun)
+	l.PreserveTrivia = true
+
+	errToken := l.NextEmittedToken().(goblex.TriviaToken)
+	assert.Equal(suite.T(), goblex.TokenTypeError, errToken.Type())
+	assert.Equal(suite.T(), "", errToken.LeadingTrivia())
+
+	custom := l.NextEmittedToken().(triviaSliceToken)
+	assert.Equal(suite.T(), "", custom.leading)
+	assert.Equal(suite.T(), "ab", custom.raw)
+	assert.Equal(suite.T(), "\n", custom.trailing)
+}
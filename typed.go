@@ -0,0 +1,187 @@
+package goblex
+
+import "iter"
+
+// TokenInfo describes an emitted token to a TokenFactory.
+type TokenInfo struct {
+	// Type is the TokenType the token was emitted with
+	Type TokenType
+	// Value is the captured value of the token
+	Value string
+	// Start is the position of the first rune of the token
+	Start Position
+	// End is the position directly following the last rune of the token
+	End Position
+	// Err is the error reported by a token with TokenTypeError
+	Err error
+	// Segments are the regions of the input the token's value was captured from
+	Segments []Segment
+	// LeadingTrivia is the input skipped before the token if PreserveTrivia is set
+	LeadingTrivia string
+	// Raw is the token as it appeared in the input if PreserveTrivia is set
+	Raw string
+	// TrailingTrivia is the input skipped after the token if PreserveTrivia is set
+	TrailingTrivia string
+}
+
+// TokenFactory creates a token of the user's own type T from an emitted token.
+type TokenFactory[T any] func(info TokenInfo) T
+
+// TypedLexer wraps a Lexer so that NextEmittedToken returns tokens of the user's own type T without
+// boxing them in a Token. Every token emitted by Emit, EmitToken, Errorf and the lexer itself, including
+// the EOF token, is built by a TokenFactory as soon as it is emitted. LexFns can also emit a T they built
+// themselves with EmitValue.
+//
+// All methods of the wrapped Lexer remain available and LexFns keep receiving the wrapped Lexer, but
+// tokens must be retrieved from the TypedLexer since the wrapped Lexer no longer queues them.
+type TypedLexer[T any] struct {
+	*Lexer
+	sink *typedSink[T]
+}
+
+// typedValue is a queued token of a TypedLexer along with the error it reports, if any.
+type typedValue[T any] struct {
+	value T
+	err   error
+}
+
+// typedSink is the tokenSink of a Lexer wrapped by a TypedLexer[T].
+type typedSink[T any] struct {
+	factory TokenFactory[T]
+	values  tokenQueue[typedValue[T]]
+}
+
+func (s *typedSink[T]) pushInfo(info TokenInfo) {
+	s.values.push(typedValue[T]{value: s.factory(info), err: info.Err})
+}
+
+func (s *typedSink[T]) pushToken(token Token) {
+	info := tokenInfo(token)
+	s.values.push(typedValue[T]{value: s.factory(info), err: info.Err})
+}
+
+func (s *typedSink[T]) pushedCount() int {
+	return s.values.pushed
+}
+
+func (s *typedSink[T]) truncate(n int) {
+	s.values.truncate(n)
+}
+
+// NewTypedLexer creates a new TypedLexer wrapping lexer that creates its tokens using factory.
+func NewTypedLexer[T any](lexer *Lexer, factory TokenFactory[T]) *TypedLexer[T] {
+	sink := &typedSink[T]{factory: factory}
+	lexer.sink = sink
+
+	return &TypedLexer[T]{
+		Lexer: lexer,
+		sink:  sink,
+	}
+}
+
+// NextEmittedToken returns the next token emitted by the wrapped lexer as a T, running the lexer until
+// a token is emitted. Once the lexer is done, the EOF token built by the factory is returned.
+func (tl *TypedLexer[T]) NextEmittedToken() T {
+	next, _ := tl.next()
+	return next.value
+}
+
+// All does the same thing as Lexer.All yielding the tokens as T's like NextEmittedToken.
+func (tl *TypedLexer[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			next, eof := tl.next()
+			if !yield(next.value) || eof {
+				return
+			}
+		}
+	}
+}
+
+// AllWithErrors does the same thing as Lexer.AllWithErrors yielding the tokens as T's like
+// NextEmittedToken.
+func (tl *TypedLexer[T]) AllWithErrors() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			next, eof := tl.next()
+			if !yield(next.value, next.err) || eof {
+				return
+			}
+		}
+	}
+}
+
+// EmitValue does the same thing as the EmitValue function for the wrapped lexer.
+func (tl *TypedLexer[T]) EmitValue(value T) {
+	EmitValue(tl.Lexer, value)
+}
+
+// next returns the next queued token and whether it is the EOF token.
+func (tl *TypedLexer[T]) next() (typedValue[T], bool) {
+	for {
+		if next, ok := tl.sink.values.pop(); ok {
+			return next, false
+		}
+
+		if eof, done := tl.Lexer.step(); done {
+			return typedValue[T]{value: tl.sink.factory(eof.info())}, true
+		}
+	}
+}
+
+// EmitValue emits value, a token of the user's own type T built by the LexFn itself, so that it is
+// returned as is by the TypedLexer[T] wrapping lxr without calling the factory. Like EmitToken, the
+// capture buffer is not cleared. This is typically called from a LexFn, e.g.
+//
+//	goblex.EmitValue(lexer, myToken{Kind: Number, Text: lexer.Flush()})
+//
+// If lxr is not wrapped by a TypedLexer[T], an error is reported instead.
+func EmitValue[T any](lxr *Lexer, value T) {
+	sink, ok := lxr.sink.(*typedSink[T])
+	if !ok {
+		lxr.errorf(CodeNone, lxr.currentPos, "EmitValue called with a %T on a lexer that is not wrapped by a TypedLexer of that type", value)
+		return
+	}
+
+	if span, ok := lxr.emitSpan(); ok {
+		lxr.emitLayout(span)
+	}
+	sink.values.push(typedValue[T]{value: value})
+	lxr.flushed = nil
+}
+
+// info returns the TokenInfo describing the token.
+func (t defaultToken) info() TokenInfo {
+	return TokenInfo{
+		Type:           t.tokenType,
+		Value:          t.value,
+		Start:          t.start,
+		End:            t.end,
+		Err:            t.Err(),
+		Segments:       t.segments,
+		LeadingTrivia:  t.trivia.leading,
+		Raw:            t.trivia.raw,
+		TrailingTrivia: t.trivia.trailing,
+	}
+}
+
+// tokenInfo returns the TokenInfo describing a token passed to EmitToken, filled in from whichever of
+// PositionedToken, SegmentedToken, TriviaToken and ErrorToken it implements.
+func tokenInfo(token Token) TokenInfo {
+	if t, ok := token.(defaultToken); ok {
+		return t.info()
+	}
+
+	info := TokenInfo{Type: token.Type(), Value: token.String(), Err: tokenErr(token)}
+	if p, ok := token.(PositionedToken); ok {
+		info.Start, info.End = p.Start(), p.End()
+	}
+	if s, ok := token.(SegmentedToken); ok {
+		info.Segments = s.Segments()
+	}
+	if t, ok := token.(TriviaToken); ok {
+		info.LeadingTrivia, info.Raw, info.TrailingTrivia = t.LeadingTrivia(), t.Raw(), t.TrailingTrivia()
+	}
+
+	return info
+}
@@ -0,0 +1,168 @@
+package goblex_test
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type numberToken struct {
+	kind   goblex.TokenType
+	text   string
+	number int64
+	line   int
+	err    error
+}
+
+func newNumberToken(info goblex.TokenInfo) numberToken {
+	n, _ := strconv.ParseInt(info.Value, 0, 64)
+	return numberToken{kind: info.Type, text: info.Value, number: n, line: info.Start.Line, err: info.Err}
+}
+
+func (suite *GoblexTestSuite) TestTypedLexer() {
+	suite.T().Parallel()
+
+	var lexFun goblex.LexFn
+	lexFun = func(lexer *goblex.Lexer) goblex.LexFn {
+		if lexer.IsEOF() {
+			return nil
+		}
+
+		if lexer.CaptureNumber() == goblex.NumberNone {
+			return lexer.Errorf("not a number")
+		}
+
+		lexer.Emit(basicTokenType)
+		return lexFun
+	}
+
+	l := goblex.NewTypedLexer(goblex.NewLexer("typed", "42 0x10\n7 x", lexFun), newNumberToken)
+
+	var tokens []numberToken
+	for {
+		token := l.NextEmittedToken()
+		tokens = append(tokens, token)
+		if token.kind == goblex.TokenTypeEOF {
+			break
+		}
+	}
+
+	assert.Len(suite.T(), tokens, 5)
+	assert.Equal(suite.T(), int64(42), tokens[0].number)
+	assert.Equal(suite.T(), int64(16), tokens[1].number)
+	assert.Equal(suite.T(), "0x10", tokens[1].text)
+	assert.Equal(suite.T(), int64(7), tokens[2].number)
+	assert.Equal(suite.T(), 2, tokens[2].line)
+	assert.Equal(suite.T(), goblex.TokenTypeError, tokens[3].kind)
+	assert.EqualError(suite.T(), tokens[3].err, "2:3: not a number")
+	assert.Equal(suite.T(), goblex.StringEOF, tokens[4].text)
+}
+
+func (suite *GoblexTestSuite) TestTypedLexerEmitValue() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureIdent()
+		goblex.EmitValue(lexer, numberToken{kind: basicTokenType, text: lexer.Flush(), number: -1})
+
+		lexer.CaptureIdent()
+		lexer.EmitToken(positionedSliceToken{value: lexer.Flush()})
+		return nil
+	}
+
+	l := goblex.NewTypedLexer(goblex.NewLexer("typed", "abc\ndef", lexFun), newNumberToken)
+
+	token := l.NextEmittedToken()
+	assert.Equal(suite.T(), numberToken{kind: basicTokenType, text: "abc", number: -1}, token)
+
+	// custom tokens keep their positions
+	token = l.NextEmittedToken()
+	assert.Equal(suite.T(), basicTokenType, token.kind)
+	assert.Equal(suite.T(), "def", token.text)
+	assert.Equal(suite.T(), 2, token.line)
+
+	// the wrapped lexer is still available
+	assert.True(suite.T(), l.IsEOF())
+}
+
+func (suite *GoblexTestSuite) TestTypedLexerBuildsTokensOnEmit() {
+	suite.T().Parallel()
+
+	built := 0
+	factory := func(info goblex.TokenInfo) numberToken {
+		built++
+		return newNumberToken(info)
+	}
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		lexer.CaptureNumber()
+		lexer.Emit(basicTokenType)
+		assert.Equal(suite.T(), 1, built)
+
+		m := lexer.Mark()
+		lexer.CaptureNumber()
+		lexer.Emit(basicTokenType)
+		lexer.Rewind(m)
+
+		lexer.CaptureNumber()
+		lexer.Emit(basicTokenType)
+		return nil
+	}
+
+	l := goblex.NewTypedLexer(goblex.NewLexer("typed", "1 2", lexFun), factory)
+
+	var numbers []int64
+	for token := range l.All() {
+		numbers = append(numbers, token.number)
+	}
+
+	// the token discarded by Rewind was built but never returned
+	assert.Equal(suite.T(), []int64{1, 2, 0}, numbers)
+	assert.Equal(suite.T(), 4, built)
+}
+
+func (suite *GoblexTestSuite) TestEmitValueWithoutTypedLexer() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		goblex.EmitValue(lexer, numberToken{text: "lost"})
+		return nil
+	}
+
+	l := goblex.NewTypedLexer(goblex.NewLexer("typed", "", lexFun), func(info goblex.TokenInfo) string {
+		return info.Value
+	})
+
+	assert.Equal(suite.T(), []string{
+		"EmitValue called with a goblex_test.numberToken on a lexer that is not wrapped by a TypedLexer of that type",
+		goblex.StringEOF,
+	}, slices.Collect(l.All()))
+}
+
+func (suite *GoblexTestSuite) TestEmitValueIndentation() {
+	suite.T().Parallel()
+
+	lexFun := func(lexer *goblex.Lexer) goblex.LexFn {
+		for lexer.CaptureIdent() {
+			goblex.EmitValue(lexer, numberToken{kind: basicTokenType, text: lexer.Flush()})
+		}
+		return nil
+	}
+
+	lexer := goblex.NewLexer("typed", "ab\n  cd", lexFun)
+	lexer.Indentation = suite.indentation(0, false)
+	l := goblex.NewTypedLexer(lexer, newNumberToken)
+
+	var kinds []goblex.TokenType
+	for token := range l.All() {
+		kinds = append(kinds, token.kind)
+	}
+
+	assert.Equal(suite.T(), []goblex.TokenType{
+		basicTokenType, newlineTokenType, indentTokenType, basicTokenType, newlineTokenType, dedentTokenType,
+		goblex.TokenTypeEOF,
+	}, kinds)
+}
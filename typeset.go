@@ -0,0 +1,144 @@
+package goblex
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// TokenTypeSet is a set of TokenTypes stored as a sparse bitset so membership can be tested in constant
+// time, e.g. to check whether a token is any literal without a long switch statement.
+//
+// Only the 64 bit words holding members are stored, so sets of registered types or of types far apart
+// stay small.
+//
+// The zero value is an empty set ready to use.
+type TokenTypeSet struct {
+	words map[int]uint64
+}
+
+// NewTokenTypeSet creates a new TokenTypeSet containing types.
+func NewTokenTypeSet(types ...TokenType) *TokenTypeSet {
+	set := &TokenTypeSet{}
+	set.Add(types...)
+
+	return set
+}
+
+// Add adds types to the set.
+func (s *TokenTypeSet) Add(types ...TokenType) {
+	for _, t := range types {
+		if s.words == nil {
+			s.words = make(map[int]uint64)
+		}
+
+		w, bit := typeBit(t)
+		s.words[w] |= bit
+	}
+}
+
+// Remove removes types from the set.
+func (s *TokenTypeSet) Remove(types ...TokenType) {
+	for _, t := range types {
+		w, bit := typeBit(t)
+		if word, ok := s.words[w]; ok {
+			if word &^= bit; word == 0 {
+				delete(s.words, w)
+			} else {
+				s.words[w] = word
+			}
+		}
+	}
+}
+
+// Contains returns whether t is in the set. A nil set contains no types.
+func (s *TokenTypeSet) Contains(t TokenType) bool {
+	if s == nil {
+		return false
+	}
+
+	w, bit := typeBit(t)
+	return s.words[w]&bit != 0
+}
+
+// Len returns the number of types in the set.
+func (s *TokenTypeSet) Len() int {
+	if s == nil {
+		return 0
+	}
+
+	n := 0
+	for _, word := range s.words {
+		n += bits.OnesCount64(word)
+	}
+
+	return n
+}
+
+// Types returns the types in the set in ascending order.
+func (s *TokenTypeSet) Types() []TokenType {
+	if s == nil {
+		return nil
+	}
+
+	indexes := make([]int, 0, len(s.words))
+	for w := range s.words {
+		indexes = append(indexes, w)
+	}
+	sort.Ints(indexes)
+
+	var types []TokenType
+	for _, w := range indexes {
+		word := s.words[w]
+		for word != 0 {
+			bit := bits.TrailingZeros64(word)
+			types = append(types, TokenType(w<<6+bit))
+			word &^= 1 << uint(bit)
+		}
+	}
+
+	return types
+}
+
+// Union returns a new TokenTypeSet of the types found in s or other.
+func (s *TokenTypeSet) Union(other *TokenTypeSet) *TokenTypeSet {
+	union := NewTokenTypeSet()
+	for _, set := range []*TokenTypeSet{s, other} {
+		if set == nil {
+			continue
+		}
+
+		for w, word := range set.words {
+			if union.words == nil {
+				union.words = make(map[int]uint64)
+			}
+			union.words[w] |= word
+		}
+	}
+
+	return union
+}
+
+// Intersect returns a new TokenTypeSet of the types found in both s and other.
+func (s *TokenTypeSet) Intersect(other *TokenTypeSet) *TokenTypeSet {
+	intersection := NewTokenTypeSet()
+	if s == nil || other == nil {
+		return intersection
+	}
+
+	for w, word := range s.words {
+		if both := word & other.words[w]; both != 0 {
+			if intersection.words == nil {
+				intersection.words = make(map[int]uint64)
+			}
+			intersection.words[w] = both
+		}
+	}
+
+	return intersection
+}
+
+// typeBit returns the index of the word holding t and the bit of t within it.
+func typeBit(t TokenType) (int, uint64) {
+	i := int(t)
+	return i >> 6, 1 << uint(i&63)
+}
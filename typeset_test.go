@@ -0,0 +1,82 @@
+package goblex_test
+
+import (
+	"github.com/brainicorn/goblex"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (suite *GoblexTestSuite) TestTokenTypeSetContains() {
+	suite.T().Parallel()
+
+	set := goblex.NewTokenTypeSet(goblex.TokenTypeError, goblex.TokenTypeEOF, basicTokenType, 1<<20)
+
+	assert.True(suite.T(), set.Contains(goblex.TokenTypeError))
+	assert.True(suite.T(), set.Contains(goblex.TokenTypeEOF))
+	assert.True(suite.T(), set.Contains(basicTokenType))
+	assert.True(suite.T(), set.Contains(1<<20))
+	assert.False(suite.T(), set.Contains(identTokenType))
+	assert.False(suite.T(), set.Contains(1<<20+1))
+	assert.False(suite.T(), set.Contains(-3))
+	assert.Equal(suite.T(), 4, set.Len())
+	assert.Equal(suite.T(), []goblex.TokenType{goblex.TokenTypeError, goblex.TokenTypeEOF, basicTokenType, 1 << 20}, set.Types())
+}
+
+func (suite *GoblexTestSuite) TestTokenTypeSetRemove() {
+	suite.T().Parallel()
+
+	set := goblex.NewTokenTypeSet(identTokenType, selectTokenType)
+	set.Remove(identTokenType, fromTokenType, 1<<20)
+
+	assert.False(suite.T(), set.Contains(identTokenType))
+	assert.True(suite.T(), set.Contains(selectTokenType))
+	assert.Equal(suite.T(), 1, set.Len())
+}
+
+func (suite *GoblexTestSuite) TestTokenTypeSetZeroAndNil() {
+	suite.T().Parallel()
+
+	var set goblex.TokenTypeSet
+	assert.False(suite.T(), set.Contains(goblex.TokenTypeEOF))
+	set.Add(goblex.TokenTypeEOF)
+	assert.True(suite.T(), set.Contains(goblex.TokenTypeEOF))
+
+	var nilSet *goblex.TokenTypeSet
+	assert.False(suite.T(), nilSet.Contains(goblex.TokenTypeEOF))
+	assert.Equal(suite.T(), 0, nilSet.Len())
+	assert.Empty(suite.T(), nilSet.Types())
+	assert.Equal(suite.T(), []goblex.TokenType{goblex.TokenTypeEOF}, nilSet.Union(&set).Types())
+	assert.Equal(suite.T(), 0, nilSet.Intersect(&set).Len())
+}
+
+func (suite *GoblexTestSuite) TestTokenTypeSetUnionIntersect() {
+	suite.T().Parallel()
+
+	low := goblex.NewTokenTypeSet(goblex.TokenTypeError, identTokenType, selectTokenType)
+	high := goblex.NewTokenTypeSet(selectTokenType, 1<<20)
+
+	assert.Equal(suite.T(), []goblex.TokenType{goblex.TokenTypeError, identTokenType, selectTokenType, 1 << 20}, low.Union(high).Types())
+	assert.Equal(suite.T(), []goblex.TokenType{selectTokenType}, low.Intersect(high).Types())
+	assert.Equal(suite.T(), 0, low.Intersect(goblex.NewTokenTypeSet(1<<20)).Len())
+
+	// the operands are left untouched
+	assert.Equal(suite.T(), 3, low.Len())
+	assert.Equal(suite.T(), 2, high.Len())
+}
+
+var typesetFarType = goblex.RegisterTokenType("typeset.FAR")
+
+func (suite *GoblexTestSuite) TestTokenTypeSetFarApartTypes() {
+	suite.T().Parallel()
+
+	set := goblex.NewTokenTypeSet(goblex.TokenTypeError, typesetFarType, 1<<24)
+
+	assert.True(suite.T(), set.Contains(goblex.TokenTypeError))
+	assert.True(suite.T(), set.Contains(typesetFarType))
+	assert.True(suite.T(), set.Contains(1<<24))
+	assert.False(suite.T(), set.Contains(1<<24-1))
+	assert.Equal(suite.T(), []goblex.TokenType{goblex.TokenTypeError, typesetFarType, 1 << 24}, set.Types())
+
+	set.Remove(typesetFarType, 1<<24)
+	assert.Equal(suite.T(), []goblex.TokenType{goblex.TokenTypeError}, set.Types())
+}